@@ -0,0 +1,229 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DecodeError is returned when a JSON value cannot be converted into the
+// protocol buffer field it is stored under.
+type DecodeError struct {
+	// Full name of the message type being decoded.
+	MessageType string
+
+	// Dotted path of the field from the message being decoded, with list
+	// indices and map keys in brackets, e.g. "repeated_nested_message[3].b".
+	Path string
+
+	// Tag number of the innermost field in Path.
+	Tag int
+
+	// The offending JSON value, with numbers as json.Number.
+	Value interface{}
+
+	// The type the JSON value was expected to convert to, e.g. "int32" or
+	// "repeated protoclosure.test_pb.TestAllTypes.NestedMessage".
+	Expected string
+
+	// The underlying parse error, if any.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	s := fmt.Sprintf("Cannot convert %T to %s", e.Value, e.Expected)
+	if e.Path != "" {
+		s += fmt.Sprintf(" at %s (tag %d)", e.Path, e.Tag)
+	}
+	if e.MessageType != "" {
+		s += fmt.Sprintf(" in %s", e.MessageType)
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the underlying parse error, if any.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownFieldError is returned when DisallowUnknown is set on an unmarshaler
+// and a message holds values that are not known fields or extensions.
+type UnknownFieldError struct {
+	// Full name of the message type being decoded.
+	MessageType string
+
+	// Dotted path of the field holding the message with the unknown values,
+	// as in DecodeError, or "" if they are in the message being decoded.
+	Path string
+
+	// The unknown Object JSON keys or PBLite array indices.
+	Keys []string
+
+	indices bool // Keys are PBLite array indices
+}
+
+func (e *UnknownFieldError) Error() string {
+	kind := "fields"
+	if e.indices {
+		kind = "indices"
+	}
+	s := "Unknown " + kind
+	if e.Path != "" {
+		s += " at " + e.Path
+	}
+	return fmt.Sprintf("%s in %s: %s", s, e.MessageType, strings.Join(e.Keys, ", "))
+}
+
+// newDecodeError returns a *DecodeError for the JSON value v that could not
+// be converted to the expected type. Its location is filled in by the callers
+// as the error is returned through the enclosing fields and messages.
+func newDecodeError(v interface{}, expected string, err error) *DecodeError {
+	return &DecodeError{Value: v, Expected: expected, Err: err}
+}
+
+// DecodeErrors lists every failure found while decoding a message when
+// AllErrors is set on an unmarshaler.
+type DecodeErrors []error
+
+func (e DecodeErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns the listed errors so that errors.As and errors.Is match any
+// of them.
+func (e DecodeErrors) Unwrap() []error {
+	return e
+}
+
+// add appends err to e, flattening the errors of nested messages into a
+// single list.
+func (e DecodeErrors) add(err error) DecodeErrors {
+	if errs, ok := err.(DecodeErrors); ok {
+		return append(e, errs...)
+	}
+	return append(e, err)
+}
+
+// err returns e, or nil if no errors were found.
+func (e DecodeErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// locatedError is implemented by the decode errors that record where in the
+// message being decoded they occurred.
+type locatedError interface {
+	error
+	addParent(name string)
+	setField(md protoreflect.MessageDescriptor, fd protoreflect.FieldDescriptor)
+}
+
+// locatedErrors returns the locatedError values in err.
+func locatedErrors(err error) []locatedError {
+	switch et := err.(type) {
+	case locatedError:
+		return []locatedError{et}
+	case DecodeErrors:
+		var les []locatedError
+		for _, e := range et {
+			if le, ok := e.(locatedError); ok {
+				les = append(les, le)
+			}
+		}
+		return les
+	}
+	return nil
+}
+
+// atIndex records that the decode errors in err occurred in the element with
+// list index or map key k of the enclosing field.
+func atIndex(err error, k interface{}) error {
+	for _, le := range locatedErrors(err) {
+		le.addParent(fmt.Sprintf("[%v]", k))
+	}
+	return err
+}
+
+// inField records that the decode errors in err occurred in field fd of a
+// message of type md.
+func inField(err error, md protoreflect.MessageDescriptor, fd protoreflect.FieldDescriptor) error {
+	for _, le := range locatedErrors(err) {
+		le.addParent(fieldPathName(fd))
+		le.setField(md, fd)
+	}
+	return err
+}
+
+func (e *DecodeError) addParent(name string) {
+	e.Path = parentPath(name, e.Path)
+}
+
+func (e *DecodeError) setField(md protoreflect.MessageDescriptor, fd protoreflect.FieldDescriptor) {
+	if e.Tag == 0 {
+		e.Tag = int(fd.Number())
+	}
+	e.MessageType = string(md.FullName())
+}
+
+func (e *UnknownFieldError) addParent(name string) {
+	e.Path = parentPath(name, e.Path)
+}
+
+func (e *UnknownFieldError) setField(md protoreflect.MessageDescriptor, _ protoreflect.FieldDescriptor) {
+	e.MessageType = string(md.FullName())
+}
+
+// parentPath prefixes path with name, the enclosing field or list index.
+func parentPath(name, path string) string {
+	if path == "" || path[0] == '[' {
+		return name + path
+	}
+	return name + "." + path
+}
+
+// fieldPathName returns the name of fd as written in field paths, with
+// extensions written by full name in parentheses.
+func fieldPathName(fd protoreflect.FieldDescriptor) string {
+	if fd.IsExtension() {
+		return "(" + string(fd.FullName()) + ")"
+	}
+	return string(fd.Name())
+}
+
+// kindName returns the name of the type of a single value of field fd.
+func kindName(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.Message() != nil:
+		return string(fd.Message().FullName())
+	case fd.Enum() != nil:
+		return string(fd.Enum().FullName())
+	default:
+		return fd.Kind().String()
+	}
+}
+
+// typeName returns the name of the type of field fd.
+func typeName(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return fmt.Sprintf("map<%s, %s>", kindName(fd.MapKey()), kindName(fd.MapValue()))
+	case fd.IsList():
+		return "repeated " + kindName(fd)
+	default:
+		return kindName(fd)
+	}
+}
@@ -0,0 +1,184 @@
+// A proto2 file used for unit testing extensions.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: extension_test.proto
+
+package extension_test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TestExtendable struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	OptionalInt32   *int32                 `protobuf:"varint,1,opt,name=optional_int32,json=optionalInt32" json:"optional_int32,omitempty"`
+	extensionFields protoimpl.ExtensionFields
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TestExtendable) Reset() {
+	*x = TestExtendable{}
+	mi := &file_extension_test_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestExtendable) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestExtendable) ProtoMessage() {}
+
+func (x *TestExtendable) ProtoReflect() protoreflect.Message {
+	mi := &file_extension_test_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestExtendable.ProtoReflect.Descriptor instead.
+func (*TestExtendable) Descriptor() ([]byte, []int) {
+	return file_extension_test_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TestExtendable) GetOptionalInt32() int32 {
+	if x != nil && x.OptionalInt32 != nil {
+		return *x.OptionalInt32
+	}
+	return 0
+}
+
+var file_extension_test_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*TestExtendable)(nil),
+		ExtensionType: (*int32)(nil),
+		Field:         10,
+		Name:          "extensiontest.optional_int32_extension",
+		Tag:           "varint,10,opt,name=optional_int32_extension",
+		Filename:      "extension_test.proto",
+	},
+	{
+		ExtendedType:  (*TestExtendable)(nil),
+		ExtensionType: (*int64)(nil),
+		Field:         11,
+		Name:          "extensiontest.optional_int64_extension",
+		Tag:           "varint,11,opt,name=optional_int64_extension",
+		Filename:      "extension_test.proto",
+	},
+	{
+		ExtendedType:  (*TestExtendable)(nil),
+		ExtensionType: (*TestExtendable)(nil),
+		Field:         12,
+		Name:          "extensiontest.optional_message_extension",
+		Tag:           "bytes,12,opt,name=optional_message_extension",
+		Filename:      "extension_test.proto",
+	},
+	{
+		ExtendedType:  (*TestExtendable)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         13,
+		Name:          "extensiontest.repeated_string_extension",
+		Tag:           "bytes,13,rep,name=repeated_string_extension",
+		Filename:      "extension_test.proto",
+	},
+}
+
+// Extension fields to TestExtendable.
+var (
+	// optional int32 optional_int32_extension = 10;
+	E_OptionalInt32Extension = &file_extension_test_proto_extTypes[0]
+	// optional int64 optional_int64_extension = 11;
+	E_OptionalInt64Extension = &file_extension_test_proto_extTypes[1]
+	// optional extensiontest.TestExtendable optional_message_extension = 12;
+	E_OptionalMessageExtension = &file_extension_test_proto_extTypes[2]
+	// repeated string repeated_string_extension = 13;
+	E_RepeatedStringExtension = &file_extension_test_proto_extTypes[3]
+)
+
+var File_extension_test_proto protoreflect.FileDescriptor
+
+const file_extension_test_proto_rawDesc = "" +
+	"\n" +
+	"\x14extension_test.proto\x12\rextensiontest\"=\n" +
+	"\x0eTestExtendable\x12%\n" +
+	"\x0eoptional_int32\x18\x01 \x01(\x05R\roptionalInt32*\x04\b\n" +
+	"\x10\x15:W\n" +
+	"\x18optional_int32_extension\x12\x1d.extensiontest.TestExtendable\x18\n" +
+	" \x01(\x05R\x16optionalInt32Extension:W\n" +
+	"\x18optional_int64_extension\x12\x1d.extensiontest.TestExtendable\x18\v \x01(\x03R\x16optionalInt64Extension:z\n" +
+	"\x1aoptional_message_extension\x12\x1d.extensiontest.TestExtendable\x18\f \x01(\v2\x1d.extensiontest.TestExtendableR\x18optionalMessageExtension:Y\n" +
+	"\x19repeated_string_extension\x12\x1d.extensiontest.TestExtendable\x18\r \x03(\tR\x17repeatedStringExtensionB Z\x1eprotoclosure/extension_test_pb"
+
+var (
+	file_extension_test_proto_rawDescOnce sync.Once
+	file_extension_test_proto_rawDescData []byte
+)
+
+func file_extension_test_proto_rawDescGZIP() []byte {
+	file_extension_test_proto_rawDescOnce.Do(func() {
+		file_extension_test_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_extension_test_proto_rawDesc), len(file_extension_test_proto_rawDesc)))
+	})
+	return file_extension_test_proto_rawDescData
+}
+
+var file_extension_test_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_extension_test_proto_goTypes = []any{
+	(*TestExtendable)(nil), // 0: extensiontest.TestExtendable
+}
+var file_extension_test_proto_depIdxs = []int32{
+	0, // 0: extensiontest.optional_int32_extension:extendee -> extensiontest.TestExtendable
+	0, // 1: extensiontest.optional_int64_extension:extendee -> extensiontest.TestExtendable
+	0, // 2: extensiontest.optional_message_extension:extendee -> extensiontest.TestExtendable
+	0, // 3: extensiontest.repeated_string_extension:extendee -> extensiontest.TestExtendable
+	0, // 4: extensiontest.optional_message_extension:type_name -> extensiontest.TestExtendable
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	4, // [4:5] is the sub-list for extension type_name
+	0, // [0:4] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_extension_test_proto_init() }
+func file_extension_test_proto_init() {
+	if File_extension_test_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_extension_test_proto_rawDesc), len(file_extension_test_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 4,
+			NumServices:   0,
+		},
+		GoTypes:           file_extension_test_proto_goTypes,
+		DependencyIndexes: file_extension_test_proto_depIdxs,
+		MessageInfos:      file_extension_test_proto_msgTypes,
+		ExtensionInfos:    file_extension_test_proto_extTypes,
+	}.Build()
+	File_extension_test_proto = out.File
+	file_extension_test_proto_goTypes = nil
+	file_extension_test_proto_depIdxs = nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldSelection is the tree of fields selected by field mask paths, keyed by
+// field number. A field mapped to nil is selected along with all of its
+// contents, and a nil fieldSelection selects every field.
+type fieldSelection map[protoreflect.FieldNumber]fieldSelection
+
+// selects reports whether all or part of field fd is selected.
+func (s fieldSelection) selects(fd protoreflect.FieldDescriptor) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[fd.Number()]
+	return ok
+}
+
+// sub returns the selection of the fields of the message held by field fd.
+func (s fieldSelection) sub(fd protoreflect.FieldDescriptor) fieldSelection {
+	return s[fd.Number()]
+}
+
+// add selects the last field of fds, unless an earlier field of fds is
+// already selected in whole.
+func (s fieldSelection) add(fds []protoreflect.FieldDescriptor) {
+	num := fds[0].Number()
+	if len(fds) == 1 {
+		s[num] = nil
+		return
+	}
+	sub, ok := s[num]
+	if ok && sub == nil {
+		return
+	}
+	if !ok {
+		sub = fieldSelection{}
+		s[num] = sub
+	}
+	sub.add(fds[1:])
+}
+
+// selectFields returns msg and a nil selection if paths is empty, or
+// otherwise a new message holding only the fields of msg selected by the
+// field mask paths, along with the selection.
+func selectFields(msg protoreflect.Message, paths []string) (protoreflect.Message, fieldSelection, error) {
+	if len(paths) == 0 {
+		return msg, nil, nil
+	}
+	fieldPaths, err := resolveFieldPaths(msg.Descriptor(), paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected := msg.New()
+	sel := fieldSelection{}
+	for _, fds := range fieldPaths {
+		copyFieldPath(selected, msg, fds)
+		sel.add(fds)
+	}
+	return selected, sel, nil
+}
+
+// applyFieldMask replaces the fields of dst selected by the field mask paths
+// with those of src, clearing the fields that are not set in src. Paths are
+// dotted field names as in google.protobuf.FieldMask, e.g.
+// "optional_nested_message.b". dst is left unchanged if a path is invalid.
+func applyFieldMask(dst, src protoreflect.Message, paths []string) error {
+	fieldPaths, err := resolveFieldPaths(dst.Descriptor(), paths)
+	if err != nil {
+		return err
+	}
+	for _, fds := range fieldPaths {
+		copyFieldPath(dst, src, fds)
+	}
+	return nil
+}
+
+// resolveFieldPaths returns the fields named by each field mask path,
+// starting from messages of type md.
+func resolveFieldPaths(md protoreflect.MessageDescriptor, paths []string) ([][]protoreflect.FieldDescriptor, error) {
+	fieldPaths := make([][]protoreflect.FieldDescriptor, len(paths))
+	for i, path := range paths {
+		fds, err := resolveFieldPath(md, path)
+		if err != nil {
+			return nil, err
+		}
+		fieldPaths[i] = fds
+	}
+	return fieldPaths, nil
+}
+
+// resolveFieldPath returns the fields named by the field mask path, starting
+// from messages of type md. Only the last field may be a scalar, repeated or
+// map field.
+func resolveFieldPath(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	var fds []protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil, fmt.Errorf("Field %s is not a singular message in field mask path %q",
+				fds[len(fds)-1].Name(), path)
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("Unknown field %s in %v for field mask path %q",
+				name, md.FullName(), path)
+		}
+		fds = append(fds, fd)
+
+		md = nil
+		if !fd.IsList() && !fd.IsMap() {
+			md = fd.Message()
+		}
+	}
+	return fds, nil
+}
+
+// copyFieldPath replaces the last field of fds in dst with its value in src,
+// following the earlier fields through nested messages.
+func copyFieldPath(dst, src protoreflect.Message, fds []protoreflect.FieldDescriptor) {
+	fd := fds[0]
+	if len(fds) > 1 {
+		if src.Has(fd) || dst.Has(fd) {
+			copyFieldPath(dst.Mutable(fd).Message(), src.Get(fd).Message(), fds[1:])
+		}
+		return
+	}
+	if src.Has(fd) {
+		dst.Set(fd, src.Get(fd))
+	} else {
+		dst.Clear(fd)
+	}
+}
@@ -5,107 +5,178 @@
 package protoclosure
 
 import (
-	"fmt"
-	"reflect"
 	"strconv"
-	"strings"
 
-	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type pbLite []interface{}
 
-func genTagMap(pb proto.Message) (int, map[int]int, map[int]struct{}) {
-	maxTagNumber := -1
-	tagMap := make(map[int]int)
-	numEncMap := make(map[int]struct{})
-	pbType := reflect.TypeOf(pb).Elem()
-	for i := 0; i < pbType.NumField(); i++ {
-		ft := pbType.Field(i)
-		if strings.HasPrefix(ft.Name, "XXX_") {
-			continue
-		}
-		p := &proto.Properties{}
-		p.Init(ft.Type, ft.Name, ft.Tag.Get("protobuf"), &ft)
-		if p.Tag > maxTagNumber {
-			maxTagNumber = p.Tag
+func (m *PBLiteMarshaler) toPBLiteValue(fi *fieldInfo, v protoreflect.Value, sel fieldSelection) (interface{}, error) {
+	fd := fi.fd
+	switch {
+	case fd.IsMap():
+		return m.toPBLiteMap(fd, v.Map())
+
+	case fd.IsList():
+		list := v.List()
+		items := make([]interface{}, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			if fd.Message() != nil {
+				item, err := m.toPBLiteMessage(list.Get(i).Message(), nil)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = item
+				continue
+			}
+			items[i] = fromProtoScalar(fd, list.Get(i), fi.numEnc(m.NumberSuffix), m.BytesEncoding,
+				m.WellKnownTypes)
 		}
-		tagMap[p.Tag] = i
-		if strings.HasSuffix(strings.ToLower(p.OrigName), "_number") {
-			numEncMap[p.Tag] = struct{}{}
+		return items, nil
+
+	case fd.Message() != nil:
+		return m.toPBLiteMessage(v.Message(), sel)
+
+	case fd.Kind() == protoreflect.BoolKind:
+		if v.Bool() {
+			return int(1), nil
 		}
+		return int(0), nil
+
+	default:
+		return fromProtoScalar(fd, v, fi.numEnc(m.NumberSuffix), m.BytesEncoding,
+			m.WellKnownTypes), nil
 	}
-	return maxTagNumber, tagMap, numEncMap
 }
 
-func toPBLiteValue(v interface{}, zeroIndex, numEnc bool) interface{} {
-	val := reflect.ValueOf(v)
-	if val.Kind() == reflect.Slice &&
-		val.Type().Elem().Implements(typeOfMessage) {
-		messages := make([]interface{}, val.Len())
-		for i := 0; i < val.Len(); i++ {
-			pb := val.Index(i).Interface().(proto.Message)
-			messages[i] = toPBLite(pb, zeroIndex)
-		}
-		return messages
-	}
-
-	switch vt := v.(type) {
-	case *int64:
-		if numEnc {
-			return v
-		}
-		return strconv.FormatInt(*vt, 10)
-	case *uint64:
-		if numEnc {
-			return v
-		}
-		return strconv.FormatUint(*vt, 10)
-	case []uint8:
-		return string(vt)
-	case proto.Message:
-		return toPBLite(vt, zeroIndex)
-	case *bool:
-		if *vt {
-			return int(1)
-		}
-		return int(0)
-	default:
-		return v
+// toPBLiteMessage encodes a message field value, writing well-known types in
+// their JSON forms when WellKnownTypes is set and expanding Any messages when
+// AnyResolver is set. EmitDefaults only applies to the fields selected by sel.
+func (m *PBLiteMarshaler) toPBLiteMessage(msg protoreflect.Message, sel fieldSelection) (interface{}, error) {
+	md := msg.Descriptor()
+	switch {
+	case m.WellKnownTypes && isWellKnown(md):
+		return fromWellKnown(msg, m.TimeAsMillis, m.BytesEncoding)
+	case m.AnyResolver != nil && isAny(md):
+		return m.toPBLiteAny(msg)
 	}
+	return m.toPBLite(msg, sel)
 }
 
-func toPBLite(pb proto.Message, zeroIndex bool) *pbLite {
+// toPBLiteAny encodes an Any message with the PBLite encoding of the message
+// it contains in place of its serialized value.
+func (m *PBLiteMarshaler) toPBLiteAny(msg protoreflect.Message) (interface{}, error) {
+	url, inner, err := unpackAny(msg, m.AnyResolver)
+	if err != nil || inner == nil {
+		return pbLite{}, err
+	}
+	value, err := m.toPBLiteMessage(inner, nil)
+	if err != nil {
+		return nil, err
+	}
+	if m.ZeroIndex {
+		return pbLite{url, value}, nil
+	}
+	return pbLite{nil, url, value}, nil
+}
+
+// toPBLiteMap encodes a map field as a list of key/value entry messages, the
+// same way maps are represented on the wire.
+func (m *PBLiteMarshaler) toPBLiteMap(fd protoreflect.FieldDescriptor, mp protoreflect.Map) (interface{}, error) {
+	emi := getMessageInfo(fd.Message())
+	keyFI, valueFI := emi.tagMap[1], emi.tagMap[2]
+
+	entries := make([]interface{}, 0, mp.Len())
+	for _, k := range sortedMapKeys(mp) {
+		key, err := m.toPBLiteValue(keyFI, k.Value(), nil)
+		if err != nil {
+			return nil, err
+		}
+		value, err := m.toPBLiteValue(valueFI, mp.Get(k), nil)
+		if err != nil {
+			return nil, err
+		}
+		if m.ZeroIndex {
+			entries = append(entries, pbLite{key, value})
+		} else {
+			entries = append(entries, pbLite{nil, key, value})
+		}
+	}
+	return entries, nil
+}
+
+// toPBLite encodes msg, writing the defaults of unset fields selected by sel
+// when EmitDefaults is set.
+func (m *PBLiteMarshaler) toPBLite(msg protoreflect.Message, sel fieldSelection) (pbLite, error) {
 	pbl := pbLite{}
 
-	maxTagNumber, tagMap, numEncMap := genTagMap(pb)
-	pbValue := reflect.ValueOf(pb).Elem()
+	mi := getMessageInfo(msg.Descriptor())
+
+	// extensions are written at their tag numbers alongside regular fields
+	maxTag := mi.maxTag
+	var extMap map[int]*fieldInfo
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.IsExtension() {
+			if extMap == nil {
+				extMap = make(map[int]*fieldInfo)
+			}
+			extMap[int(fd.Number())] = newFieldInfo(fd)
+			if int(fd.Number()) > maxTag {
+				maxTag = int(fd.Number())
+			}
+		}
+		return true
+	})
+
+	unknown := &unknownJSON{}
+	if m.EmitUnknown && len(msg.GetUnknown()) > 0 {
+		var err error
+		unknown, err = getUnknownJSON(msg)
+		if err != nil {
+			return nil, err
+		}
+		// unknown fields far beyond the known tags are dropped
+		if limit := maxTag + maxUnknownTagGap; unknown.maxTag() > limit {
+			maxTag = limit
+		} else if unknown.maxTag() > maxTag {
+			maxTag = unknown.maxTag()
+		}
+	}
 
 	startIndex := 0
-	if zeroIndex {
+	if m.ZeroIndex {
 		startIndex = 1
 	}
-	lastNonNil := -1
-	for ti := startIndex; ti <= maxTagNumber; ti++ {
-		i, ok := tagMap[ti]
+	lastNonNil := 0
+	for ti := startIndex; ti <= maxTag; ti++ {
+		fi, ok := mi.tagMap[ti]
 		if !ok {
-			pbl = append(pbl, nil)
+			fi, ok = extMap[ti]
+		}
+		if !ok {
+			v, ok := unknown.tagged[ti]
+			pbl = append(pbl, v)
+			if ok {
+				lastNonNil = len(pbl)
+			}
 			continue
 		}
-		fv := pbValue.Field(i)
 
 		// write stub markers for empty fields
-		if fv.IsNil() {
-			if fv.Kind() == reflect.Slice && fv.Type() != typeOfSliceUint8 {
-				pbl = append(pbl, []string{})
+		if !msg.Has(fi.fd) && !(m.EmitDefaults && hasDefault(fi.fd) && sel.selects(fi.fd)) {
+			if fi.fd.IsList() || fi.fd.IsMap() {
+				pbl = append(pbl, []interface{}{})
 			} else {
 				pbl = append(pbl, nil)
 			}
 			continue
 		}
 
-		_, numEnc := numEncMap[ti]
-		v := toPBLiteValue(fv.Interface(), zeroIndex, numEnc)
+		v, err := m.toPBLiteValue(fi, msg.Get(fi.fd), sel.sub(fi.fd))
+		if err != nil {
+			return nil, err
+		}
 		pbl = append(pbl, v)
 		lastNonNil = len(pbl)
 	}
@@ -113,91 +184,313 @@ func toPBLite(pb proto.Message, zeroIndex bool) *pbLite {
 	// Truncate trailing nils
 	pbl = pbl[:lastNonNil]
 
-	return &pbl
+	return pbl, nil
 }
 
-func setPBLiteField(fv *reflect.Value, v interface{}, zeroIndex bool) error {
-	if v == nil {
+func (u *PBLiteUnmarshaler) decodeOptions() decodeOptions {
+	return decodeOptions{
+		allErrors:         u.AllErrors,
+		allowLossyNumbers: u.AllowLossyNumbers,
+		bytesEncoding:     u.BytesEncoding,
+		wellKnownTypes:    u.WellKnownTypes,
+	}
+}
+
+// setPBLiteMap replaces the map field fd of msg with the key/value entry
+// messages in the decoded JSON array v.
+func (u *PBLiteUnmarshaler) setPBLiteMap(msg protoreflect.Message, fd protoreflect.FieldDescriptor, v interface{}) error {
+	entries, ok := v.([]interface{})
+	if !ok {
+		return newDecodeError(v, typeName(fd), nil)
+	}
+	if len(entries) == 0 {
 		return nil
 	}
 
-	switch fv.Kind() {
-	case reflect.Ptr:
-		if fv.Type().Implements(typeOfMessage) {
-			subMessage, ok := v.([]interface{})
-			if !ok {
-				return fmt.Errorf("Illegal JSON sub message format")
+	keyIndex, valueIndex := 1, 2
+	if u.ZeroIndex {
+		keyIndex, valueIndex = 0, 1
+	}
+
+	var errs DecodeErrors
+	mv := msg.NewField(fd)
+	mp := mv.Map()
+	for i, e := range entries {
+		entry, ok := e.([]interface{})
+		if !ok {
+			err := atIndex(newDecodeError(e, kindName(fd), nil), i)
+			if !u.AllErrors {
+				return err
 			}
-			pblSM := pbLite(subMessage)
-			newFV := reflect.New(fv.Type().Elem())
-			err := fromPBLite(&pblSM, newFV.Interface().(proto.Message), zeroIndex)
+			errs = errs.add(err)
+			continue
+		}
+		var key, value interface{}
+		if keyIndex < len(entry) {
+			key = entry[keyIndex]
+		}
+		if valueIndex < len(entry) {
+			value = entry[valueIndex]
+		}
+
+		k := fd.MapKey().Default()
+		if key != nil {
+			var err error
+			k, err = toProtoScalar(fd.MapKey(), key, u.decodeOptions())
 			if err != nil {
+				err = atIndex(err, i)
+				if !u.AllErrors {
+					return err
+				}
+				errs = errs.add(err)
+				continue
+			}
+		}
+		pv, err := u.fromPBLiteMapValue(mp, fd.MapValue(), value)
+		if err != nil {
+			err = atIndex(err, k.Interface())
+			if !u.AllErrors {
 				return err
 			}
-			fv.Set(newFV)
-			return nil
+			errs = errs.add(err)
+			if !pv.IsValid() {
+				continue
+			}
 		}
-		return setPBFieldPtr(fv, v)
+		mp.Set(k.MapKey(), pv)
+	}
+	if mp.Len() > 0 {
+		msg.Set(fd, mv)
+	}
+	return errs.err()
+}
 
-	case reflect.Slice:
-		if fv.Type().Elem().Kind() == reflect.Ptr &&
-			fv.Type().Elem().Implements(typeOfMessage) {
-			subMessageSlice, ok := v.([]interface{})
-			if !ok {
-				return fmt.Errorf("Cannot convert %T to %v", v, fv.Type())
-			}
-			newFV := reflect.MakeSlice(fv.Type(), 0, 0)
-			for _, sm := range subMessageSlice {
-				subMessage, ok := sm.([]interface{})
-				if !ok {
-					return fmt.Errorf("Cannot convert %T to %v", v, fv.Type())
-				}
-				pblSM := pbLite(subMessage)
-				newPB := reflect.New(fv.Type().Elem().Elem())
-				err := fromPBLite(&pblSM, newPB.Interface().(proto.Message), zeroIndex)
-				if err != nil {
+// fromPBLiteMapValue converts v into a value of map mp. Message values that
+// are only partly decoded are returned along with the error.
+func (u *PBLiteUnmarshaler) fromPBLiteMapValue(mp protoreflect.Map, fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	if v == nil && !readsNull(fd, u.WellKnownTypes) {
+		return mp.NewValue(), nil
+	}
+	if fd.Message() != nil {
+		return u.fromPBLiteMessage(v, mp.NewValue())
+	}
+	return toProtoScalar(fd, v, u.decodeOptions())
+}
+
+// fromPBLiteMessage decodes v into the new message value pv, reading
+// well-known types from their JSON forms when WellKnownTypes is set. Messages
+// that are only partly decoded are returned along with the error; if v cannot
+// be decoded at all the returned value is invalid.
+func (u *PBLiteUnmarshaler) fromPBLiteMessage(v interface{}, pv protoreflect.Value) (protoreflect.Value, error) {
+	md := pv.Message().Descriptor()
+	var err error
+	switch {
+	case u.WellKnownTypes && isWellKnown(md):
+		err = toWellKnown(v, pv.Message(), u.decodeOptions())
+	case u.AnyResolver != nil && isAny(md):
+		err = u.fromPBLiteAny(v, pv.Message())
+	default:
+		subMessage, ok := v.([]interface{})
+		if !ok {
+			return protoreflect.Value{}, newDecodeError(v, string(md.FullName()), nil)
+		}
+		err = u.fromPBLite(pbLite(subMessage), pv.Message())
+		return pv, err
+	}
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	return pv, nil
+}
+
+// fromPBLiteAny decodes an Any message written with the PBLite encoding of
+// the message it contains, and packs that message into msg.
+func (u *PBLiteUnmarshaler) fromPBLiteAny(v interface{}, msg protoreflect.Message) error {
+	pbl, ok := v.([]interface{})
+	if !ok {
+		return newDecodeError(v, string(anyName), nil)
+	}
+	urlIndex := 1
+	if u.ZeroIndex {
+		urlIndex = 0
+	}
+	var url, value interface{}
+	if urlIndex < len(pbl) {
+		url = pbl[urlIndex]
+	}
+	if urlIndex+1 < len(pbl) {
+		value = pbl[urlIndex+1]
+	}
+	if url == nil && value == nil {
+		// empty Any
+		return nil
+	}
+
+	typeURL, inner, err := newAnyMessage(url, u.AnyResolver)
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		_, err = u.fromPBLiteMessage(value, protoreflect.ValueOfMessage(inner))
+		if err != nil {
+			return err
+		}
+	}
+	return packAny(msg, typeURL, inner)
+}
+
+func (u *PBLiteUnmarshaler) setPBLiteField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	switch {
+	case fd.IsMap():
+		return u.setPBLiteMap(msg, fd, v)
+
+	case fd.IsList() && fd.Message() != nil:
+		subMessageSlice, ok := v.([]interface{})
+		if !ok {
+			return newDecodeError(v, typeName(fd), nil)
+		}
+		if len(subMessageSlice) == 0 {
+			return nil
+		}
+		var errs DecodeErrors
+		lv := msg.NewField(fd)
+		list := lv.List()
+		for i, sm := range subMessageSlice {
+			item, err := u.fromPBLiteMessage(sm, list.NewElement())
+			if err != nil {
+				err = atIndex(err, i)
+				if !u.AllErrors {
 					return err
 				}
-				newFV = reflect.Append(newFV, newPB)
+				errs = errs.add(err)
+				if !item.IsValid() {
+					continue
+				}
 			}
-			fv.Set(newFV)
-			return nil
+			list.Append(item)
 		}
-		return setPBFieldSlice(fv, v)
+		if list.Len() > 0 {
+			msg.Set(fd, lv)
+		}
+		return errs.err()
+
+	case fd.IsList():
+		return setProtoList(msg, fd, v, u.decodeOptions())
+
+	case fd.Message() != nil:
+		sv, err := u.fromPBLiteMessage(v, msg.NewField(fd))
+		if err != nil && (!u.AllErrors || !sv.IsValid()) {
+			return err
+		}
+		msg.Set(fd, sv)
+		return err
 
 	default:
-		return fmt.Errorf("Unsupported PBLite Kind: %v", fv.Kind())
+		pv, err := toProtoScalar(fd, v, u.decodeOptions())
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, pv)
+		return nil
 	}
 }
 
-func fromPBLite(pbl *pbLite, pb proto.Message, zeroIndex bool) error {
-	maxTagNumber, tagMap, _ := genTagMap(pb)
-	pbValue := reflect.ValueOf(pb).Elem()
+func (u *PBLiteUnmarshaler) fromPBLite(pbl pbLite, msg protoreflect.Message) error {
+	mi := getMessageInfo(msg.Descriptor())
 
 	startIndex := 1
-	if zeroIndex {
+	if u.ZeroIndex {
 		startIndex = 0
 	}
-	for ti := startIndex; ti <= maxTagNumber && ti < len(*pbl); ti++ {
-		var i int
-		var ok bool
-		if zeroIndex {
-			i, ok = tagMap[ti+1]
-		} else {
-			i, ok = tagMap[ti]
-		}
-		if !ok {
+	var oneofs oneofSet
+	var unknownIndices []string
+	var unknown map[int]interface{}
+	var errs DecodeErrors
+	for ti := startIndex; ti < len(pbl); ti++ {
+		v := pbl[ti]
+		if v == nil {
 			continue
 		}
 
-		fv := pbValue.Field(i)
-		v := (*pbl)[ti]
+		tag := ti
+		if u.ZeroIndex {
+			tag = ti + 1
+		}
+		var fd protoreflect.FieldDescriptor
+		if fi, ok := mi.tagMap[tag]; ok {
+			fd = fi.fd
+		} else if xt := findExtensionByNumber(msg.Descriptor(), tag); xt != nil {
+			fd = xt.TypeDescriptor()
+		} else {
+			if u.DisallowUnknown {
+				unknownIndices = append(unknownIndices, strconv.Itoa(ti))
+			} else if u.KeepUnknown {
+				if unknown == nil {
+					unknown = make(map[int]interface{})
+				}
+				unknown[tag] = v
+			}
+			continue
+		}
 
-		err := setPBLiteField(&fv, v, zeroIndex)
+		err := oneofs.add(fd)
+		if err == nil {
+			err = u.setPBLiteField(msg, fd, v)
+			err = inField(err, msg.Descriptor(), fd)
+		}
 		if err != nil {
+			if !u.AllErrors {
+				return err
+			}
+			errs = errs.add(err)
+		}
+	}
+
+	if len(unknownIndices) > 0 {
+		err := &UnknownFieldError{
+			MessageType: string(msg.Descriptor().FullName()),
+			Keys:        unknownIndices,
+			indices:     true,
+		}
+		if !u.AllErrors {
 			return err
 		}
+		errs = errs.add(err)
 	}
 
-	return nil
+	if u.FillDefaults {
+		setDefaults(msg)
+	}
+
+	err := addUnknownJSON(msg, unknown, nil)
+	if err != nil {
+		return err
+	}
+	return errs.err()
+}
+
+// fromPBLiteMasked decodes pbl into msg. If FieldMask is set, pbl is
+// decoded into a new message whose selected fields then replace those of msg.
+func (u *PBLiteUnmarshaler) fromPBLiteMasked(pbl pbLite, msg protoreflect.Message) error {
+	if len(u.FieldMask) == 0 {
+		return u.fromPBLite(pbl, msg)
+	}
+	decoded := msg.New()
+	err := u.fromPBLite(pbl, decoded)
+	if err != nil && !u.AllErrors {
+		return err
+	}
+	merr := applyFieldMask(msg, decoded, u.FieldMask)
+	if merr == nil {
+		// unknown values kept by KeepUnknown lie outside any field mask path
+		merr = mergeUnknownJSON(msg, decoded)
+	}
+	if merr != nil {
+		return merr
+	}
+	return err
 }
@@ -5,166 +5,478 @@
 package protoclosure
 
 import (
-	"fmt"
-	"reflect"
+	"sort"
 	"strconv"
-	"strings"
 
-	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type pbObject map[string]interface{}
 
-func toPBObjectKey(ft *reflect.StructField, tagName bool) (string, bool) {
-	p := &proto.Properties{}
-	p.Init(ft.Type, ft.Name, ft.Tag.Get("protobuf"), ft)
+func (m *PBObjectMarshaler) toPBObjectValue(fi *fieldInfo, v protoreflect.Value, sel fieldSelection) (interface{}, error) {
+	fd := fi.fd
+	switch {
+	case fd.IsMap():
+		return m.toPBObjectMap(fd, v.Map())
 
-	k := strings.ToLower(p.OrigName)
-	if tagName {
-		k = strconv.FormatInt(int64(p.Tag), 10)
+	case fd.IsList():
+		list := v.List()
+		items := make([]interface{}, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			if fd.Message() != nil {
+				item, err := m.toPBObjectMessage(list.Get(i).Message(), nil)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = item
+				continue
+			}
+			items[i] = m.toPBObjectScalar(fi, list.Get(i))
+		}
+		return items, nil
+
+	case fd.Message() != nil:
+		return m.toPBObjectMessage(v.Message(), sel)
+
+	default:
+		return m.toPBObjectScalar(fi, v), nil
 	}
-	numEnc := false
-	if strings.HasSuffix(strings.ToLower(p.OrigName), "_number") {
-		numEnc = true
+}
+
+// toPBObjectScalar converts a single non-message value of field fi, writing
+// enum values as their names when EnumsAsNames is set. Values without a name
+// in the enum descriptor are written as numbers.
+func (m *PBObjectMarshaler) toPBObjectScalar(fi *fieldInfo, v protoreflect.Value) interface{} {
+	if m.EnumsAsNames && fi.fd.Kind() == protoreflect.EnumKind &&
+		!(m.WellKnownTypes && isNullValue(fi.fd)) {
+		if ev := fi.fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
 	}
-	return k, numEnc
+	return fromProtoScalar(fi.fd, v, fi.numEnc(m.NumberSuffix), m.BytesEncoding,
+		m.WellKnownTypes)
 }
 
-func toPBObjectValue(v interface{}, tagName, numEnc bool) interface{} {
-	val := reflect.ValueOf(v)
-	if val.Kind() == reflect.Slice &&
-		val.Type().Elem().Implements(typeOfMessage) {
-		messages := make([]interface{}, val.Len())
-		for i := 0; i < val.Len(); i++ {
-			pb := val.Index(i).Interface().(proto.Message)
-			messages[i] = toPBObject(pb, tagName)
-		}
-		return messages
-	}
-
-	switch vt := v.(type) {
-	case *int64:
-		if numEnc {
-			return v
-		}
-		return strconv.FormatInt(*vt, 10)
-	case *uint64:
-		if numEnc {
-			return v
-		}
-		return strconv.FormatUint(*vt, 10)
-	case []uint8:
-		return string(vt)
-	case proto.Message:
-		return toPBObject(vt, tagName)
-	default:
-		return v
+// toPBObjectMessage encodes a message field value, writing well-known types
+// in their JSON forms when WellKnownTypes is set and expanding Any messages
+// when AnyResolver is set. EmitDefaults only applies to the fields selected by
+// sel.
+func (m *PBObjectMarshaler) toPBObjectMessage(msg protoreflect.Message, sel fieldSelection) (interface{}, error) {
+	md := msg.Descriptor()
+	switch {
+	case m.WellKnownTypes && isWellKnown(md):
+		return fromWellKnown(msg, m.TimeAsMillis, m.BytesEncoding)
+	case m.AnyResolver != nil && isAny(md):
+		return m.toPBObjectAny(msg)
 	}
+	return m.toPBObject(msg, sel)
 }
 
-func toPBObject(pb proto.Message, tagName bool) *pbObject {
-	pbo := pbObject{}
+// toPBObjectAny encodes an Any message as the fields of the message it
+// contains along with an "@type" key holding its type URL.
+func (m *PBObjectMarshaler) toPBObjectAny(msg protoreflect.Message) (interface{}, error) {
+	url, inner, err := unpackAny(msg, m.AnyResolver)
+	if err != nil || inner == nil {
+		return pbObject{}, err
+	}
+	value, err := m.toPBObjectMessage(inner, nil)
+	if err != nil {
+		return nil, err
+	}
+	obj := pbObject{}
+	if wrapsAnyValue(inner.Descriptor(), m.WellKnownTypes) {
+		obj["value"] = value
+	} else {
+		obj = value.(pbObject)
+	}
+	obj["@type"] = url
+	return obj, nil
+}
+
+// wrapsAnyValue reports whether a message of type md contained in an Any is
+// written under a "value" key, as its JSON form is not an object of fields.
+func wrapsAnyValue(md protoreflect.MessageDescriptor, wellKnownTypes bool) bool {
+	return isAny(md) || wellKnownTypes && isWellKnown(md)
+}
 
-	pbType := reflect.TypeOf(pb).Elem()
-	pbValue := reflect.ValueOf(pb).Elem()
-	for i := 0; i < pbType.NumField(); i++ {
-		ft := pbType.Field(i)
-		fv := pbValue.Field(i)
+// toPBObjectMap encodes a map field as a JSON object keyed by the map keys.
+func (m *PBObjectMarshaler) toPBObjectMap(fd protoreflect.FieldDescriptor, mp protoreflect.Map) (interface{}, error) {
+	valueFI := getMessageInfo(fd.Message()).tagMap[2]
 
-		// skip unimportant and unset fields
-		if strings.HasPrefix(ft.Name, "XXX_") {
-			continue
-		}
-		if fv.IsNil() {
+	obj := make(map[string]interface{}, mp.Len())
+	var err error
+	mp.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		obj[k.String()], err = m.toPBObjectValue(valueFI, v, nil)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// toPBObject encodes msg, writing the defaults of unset fields selected by sel
+// when EmitDefaults is set.
+func (m *PBObjectMarshaler) toPBObject(msg protoreflect.Message, sel fieldSelection) (pbObject, error) {
+	pbo := pbObject{}
+
+	for _, fi := range getMessageInfo(msg.Descriptor()).fields {
+		// skip unset fields
+		if !msg.Has(fi.fd) && !(m.EmitDefaults && hasDefault(fi.fd) && sel.selects(fi.fd)) {
 			continue
 		}
 
 		// populate pbo map with rewritten key, value pairs
-		k, numEnc := toPBObjectKey(&ft, tagName)
-		v := toPBObjectValue(fv.Interface(), tagName, numEnc)
-		pbo[k] = v
+		v, err := m.toPBObjectValue(fi, msg.Get(fi.fd), sel.sub(fi.fd))
+		if err != nil {
+			return nil, err
+		}
+		pbo[fi.key(m.KeyTag)] = v
+	}
+
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !fd.IsExtension() {
+			return true
+		}
+		fi := newFieldInfo(fd)
+		pbo[fi.key(m.KeyTag)], err = m.toPBObjectValue(fi, v, nil)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.EmitUnknown && len(msg.GetUnknown()) > 0 {
+		unknown, err := getUnknownJSON(msg)
+		if err != nil {
+			return nil, err
+		}
+		for tag, v := range unknown.tagged {
+			pbo[strconv.Itoa(tag)] = v
+		}
+		for k, v := range unknown.named {
+			pbo[k] = v
+		}
 	}
 
-	return &pbo
+	return pbo, nil
+}
+
+func (u *PBObjectUnmarshaler) decodeOptions() decodeOptions {
+	return decodeOptions{
+		allErrors:         u.AllErrors,
+		allowLossyNumbers: u.AllowLossyNumbers,
+		bytesEncoding:     u.BytesEncoding,
+		wellKnownTypes:    u.WellKnownTypes,
+	}
 }
 
-func setPBObjectField(fv *reflect.Value, v interface{}, tagName bool) error {
-	if v == nil {
+// setPBObjectMap replaces the map field fd of msg with the entries of the
+// decoded JSON object v.
+func (u *PBObjectUnmarshaler) setPBObjectMap(msg protoreflect.Message, fd protoreflect.FieldDescriptor, v interface{}) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return newDecodeError(v, typeName(fd), nil)
+	}
+	if len(obj) == 0 {
 		return nil
 	}
 
-	switch fv.Kind() {
-	case reflect.Ptr:
-		if fv.Type().Implements(typeOfMessage) {
-			subMessage, ok := v.(map[string]interface{})
-			if !ok {
-				return fmt.Errorf("Cannot convert %T to %v", v, fv.Type())
+	// decode entries in a stable order so that errors are reported
+	// deterministically
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs DecodeErrors
+	mv := msg.NewField(fd)
+	mp := mv.Map()
+	for _, key := range keys {
+		k, err := toProtoMapKey(fd.MapKey(), key)
+		if err != nil {
+			err = atIndex(err, key)
+			if !u.AllErrors {
+				return err
 			}
-			pboSM := pbObject(subMessage)
-			newFV := reflect.New(fv.Type().Elem())
-			err := fromPBObject(&pboSM, newFV.Interface().(proto.Message), tagName)
-			if err != nil {
+			errs = errs.add(err)
+			continue
+		}
+		pv, err := u.fromPBObjectMapValue(mp, fd.MapValue(), obj[key])
+		if err != nil {
+			err = atIndex(err, key)
+			if !u.AllErrors {
 				return err
 			}
-			fv.Set(newFV)
-			return nil
+			errs = errs.add(err)
+			if !pv.IsValid() {
+				continue
+			}
+		}
+		mp.Set(k, pv)
+	}
+	if mp.Len() > 0 {
+		msg.Set(fd, mv)
+	}
+	return errs.err()
+}
+
+// fromPBObjectMapValue converts v into a value of map mp. Message values that
+// are only partly decoded are returned along with the error.
+func (u *PBObjectUnmarshaler) fromPBObjectMapValue(mp protoreflect.Map, fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	if v == nil && !readsNull(fd, u.WellKnownTypes) {
+		return mp.NewValue(), nil
+	}
+	if fd.Message() != nil {
+		return u.fromPBObjectMessage(v, mp.NewValue())
+	}
+	return toProtoScalar(fd, v, u.decodeOptions())
+}
+
+// fromPBObjectMessage decodes v into the new message value pv, reading
+// well-known types from their JSON forms when WellKnownTypes is set. Messages
+// that are only partly decoded are returned along with the error; if v cannot
+// be decoded at all the returned value is invalid.
+func (u *PBObjectUnmarshaler) fromPBObjectMessage(v interface{}, pv protoreflect.Value) (protoreflect.Value, error) {
+	md := pv.Message().Descriptor()
+	var err error
+	switch {
+	case u.WellKnownTypes && isWellKnown(md):
+		err = toWellKnown(v, pv.Message(), u.decodeOptions())
+	case u.AnyResolver != nil && isAny(md):
+		err = u.fromPBObjectAny(v, pv.Message())
+	default:
+		subMessage, ok := v.(map[string]interface{})
+		if !ok {
+			return protoreflect.Value{}, newDecodeError(v, string(md.FullName()), nil)
 		}
-		return setPBFieldPtr(fv, v)
+		err = u.fromPBObject(pbObject(subMessage), pv.Message())
+		return pv, err
+	}
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	return pv, nil
+}
+
+// fromPBObjectAny decodes an Any message written as the fields of the
+// message it contains along with an "@type" key, and packs that message into
+// msg.
+func (u *PBObjectUnmarshaler) fromPBObjectAny(v interface{}, msg protoreflect.Message) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return newDecodeError(v, string(anyName), nil)
+	}
+	if len(obj) == 0 {
+		// empty Any
+		return nil
+	}
 
-	case reflect.Slice:
-		if fv.Type().Elem().Kind() == reflect.Ptr &&
-			fv.Type().Elem().Implements(typeOfMessage) {
-			subMessageSlice, ok := v.([]interface{})
-			if !ok {
-				return fmt.Errorf("Cannot convert %T to %v", v, fv.Type())
+	typeURL, inner, err := newAnyMessage(obj["@type"], u.AnyResolver)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if wrapsAnyValue(inner.Descriptor(), u.WellKnownTypes) {
+		value = obj["value"]
+	} else {
+		fields := make(map[string]interface{}, len(obj))
+		for k, fv := range obj {
+			if k != "@type" {
+				fields[k] = fv
 			}
-			newFV := reflect.MakeSlice(fv.Type(), 0, 0)
-			for _, sm := range subMessageSlice {
-				subMessage, ok := sm.(map[string]interface{})
-				if !ok {
-					return fmt.Errorf("Cannot convert %T to %v", v, fv.Type())
-				}
-				pboSM := pbObject(subMessage)
-				newPB := reflect.New(fv.Type().Elem().Elem())
-				err := fromPBObject(&pboSM, newPB.Interface().(proto.Message), tagName)
-				if err != nil {
+		}
+		value = fields
+	}
+	if value != nil {
+		_, err = u.fromPBObjectMessage(value, protoreflect.ValueOfMessage(inner))
+		if err != nil {
+			return err
+		}
+	}
+	return packAny(msg, typeURL, inner)
+}
+
+func (u *PBObjectUnmarshaler) setPBObjectField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, v interface{}) error {
+	if v == nil && !readsNull(fd, u.WellKnownTypes) {
+		return nil
+	}
+
+	switch {
+	case fd.IsMap():
+		return u.setPBObjectMap(msg, fd, v)
+
+	case fd.IsList() && fd.Message() != nil:
+		subMessageSlice, ok := v.([]interface{})
+		if !ok {
+			return newDecodeError(v, typeName(fd), nil)
+		}
+		if len(subMessageSlice) == 0 {
+			return nil
+		}
+		var errs DecodeErrors
+		lv := msg.NewField(fd)
+		list := lv.List()
+		for i, sm := range subMessageSlice {
+			item, err := u.fromPBObjectMessage(sm, list.NewElement())
+			if err != nil {
+				err = atIndex(err, i)
+				if !u.AllErrors {
 					return err
 				}
-				newFV = reflect.Append(newFV, newPB)
+				errs = errs.add(err)
+				if !item.IsValid() {
+					continue
+				}
 			}
-			fv.Set(newFV)
-			return nil
+			list.Append(item)
 		}
-		return setPBFieldSlice(fv, v)
+		if list.Len() > 0 {
+			msg.Set(fd, lv)
+		}
+		return errs.err()
+
+	case fd.IsList():
+		return setProtoList(msg, fd, v, u.decodeOptions())
+
+	case fd.Message() != nil:
+		sv, err := u.fromPBObjectMessage(v, msg.NewField(fd))
+		if err != nil && (!u.AllErrors || !sv.IsValid()) {
+			return err
+		}
+		msg.Set(fd, sv)
+		return err
 
 	default:
-		return fmt.Errorf("Unsupported PBObject Kind: %v", fv.Kind())
+		pv, err := toProtoScalar(fd, v, u.decodeOptions())
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, pv)
+		return nil
 	}
 }
 
-func fromPBObject(pbo *pbObject, pb proto.Message, tagName bool) error {
-	pbType := reflect.TypeOf(pb).Elem()
-	pbValue := reflect.ValueOf(pb).Elem()
-	for i := 0; i < pbType.NumField(); i++ {
-		ft := pbType.Field(i)
-		fv := pbValue.Field(i)
-		k, _ := toPBObjectKey(&ft, tagName)
+func (u *PBObjectUnmarshaler) fromPBObject(pbo pbObject, msg protoreflect.Message) error {
+	md := msg.Descriptor()
+	mi := getMessageInfo(md)
 
-		// skip unimportant and unset fields
-		if strings.HasPrefix(ft.Name, "XXX_") {
-			continue
+	// find keys that are not regular fields
+	var extensions []protoreflect.ExtensionType
+	var unknownKeys []string
+	if md.ExtensionRanges().Len() > 0 || u.KeepUnknown || u.DisallowUnknown {
+		for k, v := range pbo {
+			if mi.hasKey(k, u.KeyTag) {
+				continue
+			}
+			if xt := findExtension(md, k, u.KeyTag); xt != nil {
+				if v != nil {
+					extensions = append(extensions, xt)
+				}
+				continue
+			}
+			unknownKeys = append(unknownKeys, k)
 		}
-		v, ok := (*pbo)[k]
-		if !ok {
+		sort.Strings(unknownKeys)
+	}
+	var errs DecodeErrors
+	if u.DisallowUnknown && len(unknownKeys) > 0 {
+		err := &UnknownFieldError{MessageType: string(md.FullName()), Keys: unknownKeys}
+		if !u.AllErrors {
+			return err
+		}
+		errs = errs.add(err)
+	}
+
+	var oneofs oneofSet
+	for _, fi := range mi.fields {
+		// skip unset fields
+		v, ok := pbo[fi.key(u.KeyTag)]
+		if !ok || v == nil && !readsNull(fi.fd, u.WellKnownTypes) {
 			continue
 		}
+		err := oneofs.add(fi.fd)
+		if err == nil {
+			// populate msg with rewritten value
+			err = u.setPBObjectField(msg, fi.fd, v)
+			err = inField(err, md, fi.fd)
+		}
+		if err != nil {
+			if !u.AllErrors {
+				return err
+			}
+			errs = errs.add(err)
+		}
+	}
 
-		// populate fv with rewritten value
-		err := setPBObjectField(&fv, v, tagName)
+	sort.Slice(extensions, func(i, j int) bool {
+		return extensions[i].TypeDescriptor().Number() < extensions[j].TypeDescriptor().Number()
+	})
+	for _, xt := range extensions {
+		xd := xt.TypeDescriptor()
+		err := u.setPBObjectField(msg, xd, pbo[newFieldInfo(xd).key(u.KeyTag)])
 		if err != nil {
-			return err
+			err = inField(err, md, xd)
+			if !u.AllErrors {
+				return err
+			}
+			errs = errs.add(err)
+		}
+	}
+
+	if u.FillDefaults {
+		setDefaults(msg)
+	}
+
+	if !u.KeepUnknown {
+		return errs.err()
+	}
+
+	var tagged map[int]interface{}
+	var named map[string]interface{}
+	for _, k := range unknownKeys {
+		if pbo[k] == nil {
+			continue
 		}
+		if tag := unknownTag(k); u.KeyTag && tag > 0 {
+			if tagged == nil {
+				tagged = make(map[int]interface{})
+			}
+			tagged[tag] = pbo[k]
+			continue
+		}
+		if named == nil {
+			named = make(map[string]interface{})
+		}
+		named[k] = pbo[k]
 	}
+	err := addUnknownJSON(msg, tagged, named)
+	if err != nil {
+		return err
+	}
+	return errs.err()
+}
 
-	return nil
+// fromPBObjectMasked decodes pbo into msg. If FieldMask is set, pbo is
+// decoded into a new message whose selected fields then replace those of msg.
+func (u *PBObjectUnmarshaler) fromPBObjectMasked(pbo pbObject, msg protoreflect.Message) error {
+	if len(u.FieldMask) == 0 {
+		return u.fromPBObject(pbo, msg)
+	}
+	decoded := msg.New()
+	err := u.fromPBObject(pbo, decoded)
+	if err != nil && !u.AllErrors {
+		return err
+	}
+	merr := applyFieldMask(msg, decoded, u.FieldMask)
+	if merr == nil {
+		// unknown values kept by KeepUnknown lie outside any field mask path
+		merr = mergeUnknownJSON(msg, decoded)
+	}
+	if merr != nil {
+		return merr
+	}
+	return err
 }
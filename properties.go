@@ -0,0 +1,171 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// fieldInfo describes how a single protocol buffer field is represented in
+// the PBLite and PBObject formats.
+type fieldInfo struct {
+	fd        protoreflect.FieldDescriptor
+	name      string                           // Object JSON key when keyed by field name
+	tagKey    string                           // Object JSON key when keyed by tag number
+	jsType    descriptorpb.FieldOptions_JSType // jstype field option
+	numSuffix bool                             // name ends in "_number"
+}
+
+// numEnc reports whether 64-bit integers of the field are written as JSON
+// numbers rather than strings. This is set by the jstype field option. The
+// "_number" name suffix is only used as a fallback for fields without the
+// option when numberSuffix is set.
+func (fi *fieldInfo) numEnc(numberSuffix bool) bool {
+	switch fi.jsType {
+	case descriptorpb.FieldOptions_JS_NUMBER:
+		return true
+	case descriptorpb.FieldOptions_JS_STRING:
+		return false
+	default:
+		return numberSuffix && fi.numSuffix
+	}
+}
+
+// key returns the Object JSON key of the field.
+func (fi *fieldInfo) key(keyTag bool) string {
+	if keyTag {
+		return fi.tagKey
+	}
+	return fi.name
+}
+
+// messageInfo describes the fields of a protocol buffer message type.
+type messageInfo struct {
+	maxTag int                   // largest tag number, -1 if there are no fields
+	fields []*fieldInfo          // fields in declaration order
+	tagMap map[int]*fieldInfo    // fields by tag number
+	keyMap map[string]*fieldInfo // fields by Object JSON name and tag keys
+}
+
+// hasKey reports whether k is the Object JSON key of a field.
+func (mi *messageInfo) hasKey(k string, keyTag bool) bool {
+	fi, ok := mi.keyMap[k]
+	return ok && fi.key(keyTag) == k
+}
+
+var (
+	messageInfoMu    sync.RWMutex
+	messageInfoCache = make(map[protoreflect.MessageDescriptor]*messageInfo)
+)
+
+// getMessageInfo returns the field metadata for the message type described
+// by md, computing it on first use. Only the metadata of descriptors in the
+// global registry is cached, as descriptors built at runtime (for example
+// for dynamicpb messages) may be created without bound.
+func getMessageInfo(md protoreflect.MessageDescriptor) *messageInfo {
+	messageInfoMu.RLock()
+	mi, ok := messageInfoCache[md]
+	messageInfoMu.RUnlock()
+	if ok {
+		return mi
+	}
+
+	mi = newMessageInfo(md)
+	if !isRegistered(md) {
+		return mi
+	}
+
+	messageInfoMu.Lock()
+	messageInfoCache[md] = mi
+	messageInfoMu.Unlock()
+	return mi
+}
+
+// isRegistered reports whether md is the descriptor registered under its
+// name in protoregistry.GlobalFiles.
+func isRegistered(md protoreflect.MessageDescriptor) bool {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(md.FullName())
+	return err == nil && d == md
+}
+
+func newMessageInfo(md protoreflect.MessageDescriptor) *messageInfo {
+	mi := &messageInfo{
+		maxTag: -1,
+		tagMap: make(map[int]*fieldInfo),
+		keyMap: make(map[string]*fieldInfo),
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fi := newFieldInfo(fields.Get(i))
+		tag := int(fi.fd.Number())
+		if tag > mi.maxTag {
+			mi.maxTag = tag
+		}
+		mi.fields = append(mi.fields, fi)
+		mi.tagMap[tag] = fi
+		mi.keyMap[fi.name] = fi
+		mi.keyMap[fi.tagKey] = fi
+	}
+	return mi
+}
+
+// newFieldInfo returns the field metadata for fd. Extensions are keyed by
+// their full name so that they cannot collide with regular fields.
+func newFieldInfo(fd protoreflect.FieldDescriptor) *fieldInfo {
+	// field names are lowercased, which also gives group fields the name
+	// goog.proto2 uses whether the descriptor names them after the group
+	// type (as older generated code does) or its lowercased form
+	name := strings.ToLower(string(fd.Name()))
+	fi := &fieldInfo{
+		fd:        fd,
+		name:      name,
+		tagKey:    strconv.Itoa(int(fd.Number())),
+		numSuffix: strings.HasSuffix(name, "_number"),
+	}
+	if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok {
+		fi.jsType = opts.GetJstype()
+	}
+	if fd.IsExtension() {
+		fi.name = string(fd.FullName())
+	}
+	return fi
+}
+
+// findExtension returns the registered extension of md identified by the
+// Object JSON key k, or nil if there is none.
+func findExtension(md protoreflect.MessageDescriptor, k string, keyTag bool) protoreflect.ExtensionType {
+	if keyTag {
+		tag, err := strconv.Atoi(k)
+		if err != nil {
+			return nil
+		}
+		return findExtensionByNumber(md, tag)
+	}
+	xt, err := protoregistry.GlobalTypes.FindExtensionByName(protoreflect.FullName(k))
+	if err != nil || xt.TypeDescriptor().ContainingMessage().FullName() != md.FullName() {
+		return nil
+	}
+	return xt
+}
+
+// findExtensionByNumber returns the registered extension of md with tag
+// number tag, or nil if there is none.
+func findExtensionByNumber(md protoreflect.MessageDescriptor, tag int) protoreflect.ExtensionType {
+	num := protoreflect.FieldNumber(tag)
+	if !md.ExtensionRanges().Has(num) {
+		return nil
+	}
+	xt, err := protoregistry.GlobalTypes.FindExtensionByNumber(md.FullName(), num)
+	if err != nil {
+		return nil
+	}
+	return xt
+}
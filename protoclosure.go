@@ -4,78 +4,399 @@
 
 // Package protoclosure implements a JSON-based interface for sharing protocol
 // buffer messges between goprotobuf and closure-library's goog.proto2.
+//
+// Messages are read and written through protoreflect, so generated messages
+// from either protobuf API version and dynamicpb messages are supported.
 package protoclosure
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 
 	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
+// PBLiteMarshaler is a configurable object for converting protocol buffers
+// into the PBLite JSON format.
+type PBLiteMarshaler struct {
+	// Whether to store the field with tag number 1 at array index 0 rather
+	// than leaving index 0 unused.
+	ZeroIndex bool
+
+	// Whether to write the unknown fields of messages at their tag numbers.
+	EmitUnknown bool
+
+	// Whether to write unset fields that have a declared default value, or
+	// proto3 fields without explicit presence, with their effective values.
+	EmitDefaults bool
+
+	// Whether to write 64-bit integer fields without a jstype option as JSON
+	// numbers when their names end in "_number", for legacy schemas that
+	// cannot set the option.
+	NumberSuffix bool
+
+	// Encoding used to write bytes fields as base64 strings, e.g.
+	// base64.StdEncoding or base64.URLEncoding. If nil, bytes are written as
+	// the characters of a string, as goog.proto2 does, which corrupts bytes
+	// that are not valid UTF-8.
+	BytesEncoding *base64.Encoding
+
+	// Whether to write the google.protobuf Timestamp, Duration and wrapper
+	// messages in their JSON forms: Timestamps as RFC 3339 strings, Durations
+	// as decimal seconds with an "s" suffix (e.g. "1.500s"), and wrappers as
+	// their bare values.
+	WellKnownTypes bool
+
+	// Whether, with WellKnownTypes, to write Timestamps and Durations as
+	// integer numbers of milliseconds instead, dropping any finer precision.
+	TimeAsMillis bool
+
+	// Resolver used to look up the types of google.protobuf.Any messages,
+	// e.g. protoregistry.GlobalTypes. If set, Any messages are written as the
+	// message they contain, tagged with its type URL.
+	AnyResolver protoregistry.MessageTypeResolver
+
+	// Dotted paths of the fields to write, e.g. "optional_nested_message.b",
+	// as in the paths of a google.protobuf.FieldMask. If empty, all fields
+	// are written. EmitDefaults also only applies to the selected fields.
+	FieldMask []string
+
+	// Whether to return a *RequiredNotSetError, along with the encoded data,
+	// when required fields are not set. Only the fields selected by FieldMask
+	// are checked.
+	CheckRequired bool
+}
+
+// Marshal takes the protocol buffer and encodes it into the PBLite JSON
+// format, returning the data.
+func (m *PBLiteMarshaler) Marshal(pb proto.Message) ([]byte, error) {
+	pbl, err := m.encode(pb)
+	if pbl == nil {
+		return nil, err
+	}
+	data, jerr := json.Marshal(pbl)
+	if jerr != nil {
+		return nil, jerr
+	}
+	return data, err
+}
+
+// encode converts pb into the value written as JSON. A *RequiredNotSetError
+// is returned along with the value; on other errors the value is nil.
+func (m *PBLiteMarshaler) encode(pb proto.Message) (pbLite, error) {
+	msg := proto.MessageReflect(pb)
+	selected, sel, err := selectFields(msg, m.FieldMask)
+	if err != nil {
+		return nil, err
+	}
+	pbl, err := m.toPBLite(selected, sel)
+	if err != nil || !m.CheckRequired {
+		return pbl, err
+	}
+	return pbl, checkRequired(msg, sel)
+}
+
+// PBLiteUnmarshaler is a configurable object for converting PBLite JSON into
+// protocol buffers.
+type PBLiteUnmarshaler struct {
+	// Whether the field with tag number 1 is stored at array index 0 rather
+	// than at array index 1.
+	ZeroIndex bool
+
+	// Whether to keep values at unknown array indices in the unknown fields
+	// of messages instead of discarding them.
+	KeepUnknown bool
+
+	// Whether to return an error when a non-null value is found at an array
+	// index that is not a known field or registered extension.
+	DisallowUnknown bool
+
+	// Whether to set fields absent from the input that have a declared
+	// default value to that value.
+	FillDefaults bool
+
+	// Whether to return a *RequiredNotSetError, after fully decoding the
+	// message, when required fields are not set. With a FieldMask the whole
+	// updated message is checked, as fields outside the mask are kept too.
+	CheckRequired bool
+
+	// Whether to keep decoding after a field fails to decode, building as
+	// much of the message as possible and returning every failure in a
+	// DecodeErrors list.
+	AllErrors bool
+
+	// Whether to convert fractional and out of range numbers into integer
+	// fields by truncating and wrapping them, rather than returning an error.
+	AllowLossyNumbers bool
+
+	// Encoding used to read bytes fields from base64 strings. If nil, the
+	// characters of the string are used as the bytes.
+	BytesEncoding *base64.Encoding
+
+	// Whether to read the google.protobuf Timestamp, Duration and wrapper
+	// messages from their JSON forms, accepting Timestamps and Durations as
+	// either strings or integer numbers of milliseconds.
+	WellKnownTypes bool
+
+	// Resolver used to look up the types of google.protobuf.Any messages
+	// written by a marshaler with AnyResolver set.
+	AnyResolver protoregistry.MessageTypeResolver
+
+	// Dotted paths of the fields to decode, as in the paths of a
+	// google.protobuf.FieldMask. If set, only the selected fields of the
+	// message are replaced, and those absent from the input are cleared,
+	// leaving the other fields of the message unchanged.
+	FieldMask []string
+}
+
+// Unmarshal parses the PBLite JSON format protocol buffer representation in
+// data and places the decoded result in pb.
+func (u *PBLiteUnmarshaler) Unmarshal(data []byte, pb proto.Message) error {
+	pbl := pbLite{}
+	err := unmarshalJSON(data, &pbl)
+	if err != nil {
+		return err
+	}
+	return u.decode(pbl, pb)
+}
+
+// decode places the decoded JSON value pbl in pb.
+func (u *PBLiteUnmarshaler) decode(pbl pbLite, pb proto.Message) error {
+	msg := proto.MessageReflect(pb)
+	err := u.fromPBLiteMasked(pbl, msg)
+	if err != nil || !u.CheckRequired {
+		return err
+	}
+	return checkRequired(msg, nil)
+}
+
+// PBObjectMarshaler is a configurable object for converting protocol buffers
+// into the Object JSON format.
+type PBObjectMarshaler struct {
+	// Whether to use tag numbers rather than field names as the JSON keys.
+	KeyTag bool
+
+	// Whether to write the unknown fields of messages under their original
+	// keys.
+	EmitUnknown bool
+
+	// Whether to write enum values as their names rather than their numbers.
+	EnumsAsNames bool
+
+	// Whether to write unset fields that have a declared default value, or
+	// proto3 fields without explicit presence, with their effective values.
+	EmitDefaults bool
+
+	// Whether to write 64-bit integer fields without a jstype option as JSON
+	// numbers when their names end in "_number", for legacy schemas that
+	// cannot set the option.
+	NumberSuffix bool
+
+	// Encoding used to write bytes fields as base64 strings, e.g.
+	// base64.StdEncoding or base64.URLEncoding. If nil, bytes are written as
+	// the characters of a string, as goog.proto2 does, which corrupts bytes
+	// that are not valid UTF-8.
+	BytesEncoding *base64.Encoding
+
+	// Whether to write the google.protobuf Timestamp, Duration and wrapper
+	// messages in their JSON forms: Timestamps as RFC 3339 strings, Durations
+	// as decimal seconds with an "s" suffix (e.g. "1.500s"), and wrappers as
+	// their bare values.
+	WellKnownTypes bool
+
+	// Whether, with WellKnownTypes, to write Timestamps and Durations as
+	// integer numbers of milliseconds instead, dropping any finer precision.
+	TimeAsMillis bool
+
+	// Resolver used to look up the types of google.protobuf.Any messages,
+	// e.g. protoregistry.GlobalTypes. If set, Any messages are written as the
+	// message they contain, tagged with its type URL.
+	AnyResolver protoregistry.MessageTypeResolver
+
+	// Dotted paths of the fields to write, e.g. "optional_nested_message.b",
+	// as in the paths of a google.protobuf.FieldMask. If empty, all fields
+	// are written. EmitDefaults also only applies to the selected fields.
+	FieldMask []string
+
+	// Whether to return a *RequiredNotSetError, along with the encoded data,
+	// when required fields are not set. Only the fields selected by FieldMask
+	// are checked.
+	CheckRequired bool
+}
+
+// Marshal takes the protocol buffer and encodes it into the Object JSON
+// format, returning the data.
+func (m *PBObjectMarshaler) Marshal(pb proto.Message) ([]byte, error) {
+	pbo, err := m.encode(pb)
+	if pbo == nil {
+		return nil, err
+	}
+	data, jerr := json.Marshal(pbo)
+	if jerr != nil {
+		return nil, jerr
+	}
+	return data, err
+}
+
+// encode converts pb into the value written as JSON. A *RequiredNotSetError
+// is returned along with the value; on other errors the value is nil.
+func (m *PBObjectMarshaler) encode(pb proto.Message) (pbObject, error) {
+	msg := proto.MessageReflect(pb)
+	selected, sel, err := selectFields(msg, m.FieldMask)
+	if err != nil {
+		return nil, err
+	}
+	pbo, err := m.toPBObject(selected, sel)
+	if err != nil || !m.CheckRequired {
+		return pbo, err
+	}
+	return pbo, checkRequired(msg, sel)
+}
+
+// PBObjectUnmarshaler is a configurable object for converting Object JSON
+// into protocol buffers.
+type PBObjectUnmarshaler struct {
+	// Whether the JSON keys are tag numbers rather than field names.
+	KeyTag bool
+
+	// Whether to keep values under unknown keys in the unknown fields of
+	// messages instead of discarding them.
+	KeepUnknown bool
+
+	// Whether to return an error when a key is not a known field or
+	// registered extension.
+	DisallowUnknown bool
+
+	// Whether to set fields absent from the input that have a declared
+	// default value to that value.
+	FillDefaults bool
+
+	// Whether to return a *RequiredNotSetError, after fully decoding the
+	// message, when required fields are not set. With a FieldMask the whole
+	// updated message is checked, as fields outside the mask are kept too.
+	CheckRequired bool
+
+	// Whether to keep decoding after a field fails to decode, building as
+	// much of the message as possible and returning every failure in a
+	// DecodeErrors list.
+	AllErrors bool
+
+	// Whether to convert fractional and out of range numbers into integer
+	// fields by truncating and wrapping them, rather than returning an error.
+	AllowLossyNumbers bool
+
+	// Encoding used to read bytes fields from base64 strings. If nil, the
+	// characters of the string are used as the bytes.
+	BytesEncoding *base64.Encoding
+
+	// Whether to read the google.protobuf Timestamp, Duration and wrapper
+	// messages from their JSON forms, accepting Timestamps and Durations as
+	// either strings or integer numbers of milliseconds.
+	WellKnownTypes bool
+
+	// Resolver used to look up the types of google.protobuf.Any messages
+	// written by a marshaler with AnyResolver set.
+	AnyResolver protoregistry.MessageTypeResolver
+
+	// Dotted paths of the fields to decode, as in the paths of a
+	// google.protobuf.FieldMask. If set, only the selected fields of the
+	// message are replaced, and those absent from the input are cleared,
+	// leaving the other fields of the message unchanged.
+	FieldMask []string
+}
+
+// Unmarshal parses the Object JSON format protocol buffer representation in
+// data and places the decoded result in pb.
+func (u *PBObjectUnmarshaler) Unmarshal(data []byte, pb proto.Message) error {
+	pbo := pbObject{}
+	err := unmarshalJSON(data, &pbo)
+	if err != nil {
+		return err
+	}
+	return u.decode(pbo, pb)
+}
+
+// decode places the decoded JSON value pbo in pb.
+func (u *PBObjectUnmarshaler) decode(pbo pbObject, pb proto.Message) error {
+	msg := proto.MessageReflect(pb)
+	err := u.fromPBObjectMasked(pbo, msg)
+	if err != nil || !u.CheckRequired {
+		return err
+	}
+	return checkRequired(msg, nil)
+}
+
 // MarshalPBLite takes the protocol buffer and encodes it into the PBLite JSON
 // format, returning the data.
 func MarshalPBLite(pb proto.Message) ([]byte, error) {
-	return json.Marshal(toPBLite(pb, false))
+	m := &PBLiteMarshaler{}
+	return m.Marshal(pb)
 }
 
 // MarshalPBLiteZeroIndex takes the protocol buffer and encodes it into the
 // zero-indexed PBLite JSON format, returning the data.
 func MarshalPBLiteZeroIndex(pb proto.Message) ([]byte, error) {
-	return json.Marshal(toPBLite(pb, true))
+	m := &PBLiteMarshaler{ZeroIndex: true}
+	return m.Marshal(pb)
 }
 
 // MarshalObjectKeyName takes the protocol buffer and encodes it into the
 // Object JSON format using field names as the JSON keys, returning the data.
 func MarshalObjectKeyName(pb proto.Message) ([]byte, error) {
-	return json.Marshal(toPBObject(pb, false))
+	m := &PBObjectMarshaler{}
+	return m.Marshal(pb)
 }
 
 // MarshalObjectKeyTag takes the protocol buffer and encodes it into the Object
 // JSON format using tag numbers as the JSON keys, returning the data.
 func MarshalObjectKeyTag(pb proto.Message) ([]byte, error) {
-	return json.Marshal(toPBObject(pb, true))
+	m := &PBObjectMarshaler{KeyTag: true}
+	return m.Marshal(pb)
 }
 
 // UnmarshalPBLite parses the PBLite JSON format protocol buffer representation
 // in data and places the decoded result in pb.
 func UnmarshalPBLite(data []byte, pb proto.Message) error {
-	pbl := &pbLite{}
-	err := json.Unmarshal(data, pbl)
-	if err != nil {
-		return err
-	}
-	return fromPBLite(pbl, pb, false)
+	u := &PBLiteUnmarshaler{}
+	return u.Unmarshal(data, pb)
 }
 
 // UnmarshalPBLiteZeroIndex parses the zero-indexed PBLite JSON format protocol
 // buffer representation in data and places the decoded result in pb.
 func UnmarshalPBLiteZeroIndex(data []byte, pb proto.Message) error {
-	pbl := &pbLite{}
-	err := json.Unmarshal(data, pbl)
-	if err != nil {
-		return err
-	}
-	return fromPBLite(pbl, pb, true)
+	u := &PBLiteUnmarshaler{ZeroIndex: true}
+	return u.Unmarshal(data, pb)
 }
 
 // UnmarshalObjectKeyName parses the field name based Object JSON format
 // protocol buffer representation in data and places the decoded result in pb.
 func UnmarshalObjectKeyName(data []byte, pb proto.Message) error {
-	pbo := &pbObject{}
-	err := json.Unmarshal(data, pbo)
-	if err != nil {
-		return err
-	}
-	return fromPBObject(pbo, pb, false)
+	u := &PBObjectUnmarshaler{}
+	return u.Unmarshal(data, pb)
 }
 
 // UnmarshalObjectKeyTag parses the tag number based Object JSON format
 // protocol buffer representation in data and places the decoded result in pb.
 func UnmarshalObjectKeyTag(data []byte, pb proto.Message) error {
-	pbo := &pbObject{}
-	err := json.Unmarshal(data, pbo)
+	u := &PBObjectUnmarshaler{KeyTag: true}
+	return u.Unmarshal(data, pb)
+}
+
+// unmarshalJSON parses the JSON value in data like json.Unmarshal, but decodes
+// numbers as json.Number so that 64-bit integers keep their full precision.
+func unmarshalJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(v)
 	if err != nil {
 		return err
 	}
-	return fromPBObject(pbo, pb, true)
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("Unexpected data after top-level JSON value")
+	}
+	return nil
 }
@@ -6,12 +6,38 @@ package protoclosure
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
-
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	extension_test_pb "protoclosure/extension_test_pb"
 	package_test_pb "protoclosure/package_test_pb"
+	required_test_pb "protoclosure/required_test_pb"
+	test3_pb "protoclosure/test3_pb"
 	test_pb "protoclosure/test_pb"
+	well_known_test_pb "protoclosure/well_known_test_pb"
 )
 
 // JS limits integers stored in Numbers (not strings) to the IEEE-754 floating
@@ -1009,3 +1035,2747 @@ func TestUnmarshalObjectKeyTagEscape(t *testing.T) {
 		t.Errorf("Found %s, want %s", string(pb.OptionalBytes), specialCharString)
 	}
 }
+
+func TestPBLiteMarshalerZeroIndex(t *testing.T) {
+	pb := &test_pb.TestAllTypes{}
+	populateMessage(pb)
+
+	m := &PBLiteMarshaler{ZeroIndex: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteZeroIndexGolden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteZeroIndexGolden)
+	}
+
+	u := &PBLiteUnmarshaler{ZeroIndex: true}
+	pb = &test_pb.TestAllTypes{}
+	err = u.Unmarshal(s, pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	validateMessage(t, pb)
+}
+
+func TestPBObjectMarshalerKeyTag(t *testing.T) {
+	pb := &test_pb.TestAllTypes{}
+	populateMessage(pb)
+
+	m := &PBObjectMarshaler{KeyTag: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyTagGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyTagGolden)
+	}
+
+	u := &PBObjectUnmarshaler{KeyTag: true}
+	pb = &test_pb.TestAllTypes{}
+	err = u.Unmarshal(s, pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	validateMessage(t, pb)
+}
+
+func TestPBLiteEncoder(t *testing.T) {
+	pb := &test_pb.TestAllTypes{}
+	populateMessage(pb)
+
+	var buf bytes.Buffer
+	enc := NewPBLiteEncoder(&buf)
+	for i := 0; i < 2; i++ {
+		err := enc.Encode(pb)
+		if err != nil {
+			t.Fatalf("unable to Encode: %v", err)
+		}
+	}
+	want := pbLiteGolden + "\n" + pbLiteGolden + "\n"
+	if buf.String() != want {
+		t.Errorf("Found %s, want %s", buf.String(), want)
+	}
+}
+
+func TestPBLiteDecoder(t *testing.T) {
+	r := bytes.NewBufferString(pbLiteGolden + "\n" + pbLiteGolden)
+	dec := NewPBLiteDecoder(r)
+	n := 0
+	for {
+		pb := &test_pb.TestAllTypes{}
+		err := dec.Decode(pb)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unable to Decode: %v", err)
+		}
+		validateMessage(t, pb)
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Found %d messages, want 2", n)
+	}
+}
+
+func TestPBObjectEncoder(t *testing.T) {
+	pb := &test_pb.TestAllTypes{}
+	populateMessage(pb)
+
+	var buf bytes.Buffer
+	m := &PBObjectMarshaler{KeyTag: true}
+	enc := m.NewEncoder(&buf)
+	for i := 0; i < 2; i++ {
+		err := enc.Encode(pb)
+		if err != nil {
+			t.Fatalf("unable to Encode: %v", err)
+		}
+	}
+	want := objectKeyTagGolden + "\n" + objectKeyTagGolden + "\n"
+	if buf.String() != want {
+		t.Errorf("Found %s, want %s", buf.String(), want)
+	}
+}
+
+func TestPBObjectDecoder(t *testing.T) {
+	r := bytes.NewBufferString(objectKeyNameGolden + objectKeyNameGolden)
+	dec := NewPBObjectDecoder(r)
+	n := 0
+	for {
+		pb := &test_pb.TestAllTypes{}
+		err := dec.Decode(pb)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unable to Decode: %v", err)
+		}
+		validateMessage(t, pb)
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Found %d messages, want 2", n)
+	}
+}
+
+func benchmarkMessage() *package_test_pb.TestPackageTypes {
+	pb := &package_test_pb.TestPackageTypes{}
+	pb.OptionalInt32 = proto.Int32(1)
+	for i := 0; i < 100; i++ {
+		testMessage := &test_pb.TestAllTypes{}
+		populateMessage(testMessage)
+		for j := 0; j < 10; j++ {
+			nestedMessage := &test_pb.TestAllTypes_NestedMessage{}
+			nestedMessage.B = proto.Int32(int32(j))
+			testMessage.RepeatedNestedMessage = append(
+				testMessage.RepeatedNestedMessage, nestedMessage)
+		}
+		pb.RepOtherAll = append(pb.RepOtherAll, testMessage)
+	}
+	return pb
+}
+
+func BenchmarkMarshalPBLite(b *testing.B) {
+	pb := benchmarkMessage()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := MarshalPBLite(pb)
+		if err != nil {
+			b.Fatalf("unable to MarshalPBLite: %v", err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalPBLite(b *testing.B) {
+	data, err := MarshalPBLite(benchmarkMessage())
+	if err != nil {
+		b.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pb := &package_test_pb.TestPackageTypes{}
+		err := UnmarshalPBLite(data, pb)
+		if err != nil {
+			b.Fatalf("unable to UnmarshalPBLite: %v", err)
+		}
+	}
+}
+
+func BenchmarkMarshalObjectKeyName(b *testing.B) {
+	pb := benchmarkMessage()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := MarshalObjectKeyName(pb)
+		if err != nil {
+			b.Fatalf("unable to MarshalObjectKeyName: %v", err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalObjectKeyName(b *testing.B) {
+	data, err := MarshalObjectKeyName(benchmarkMessage())
+	if err != nil {
+		b.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pb := &package_test_pb.TestPackageTypes{}
+		err := UnmarshalObjectKeyName(data, pb)
+		if err != nil {
+			b.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+		}
+	}
+}
+
+func TestMarshalPBLiteConcurrent(t *testing.T) {
+	pb := &package_test_pb.TestPackageTypes{}
+	pb.OptionalInt32 = proto.Int32(1)
+	testMessage := &test_pb.TestAllTypes{}
+	populateMessage(testMessage)
+	pb.OtherAll = testMessage
+	pb.RepOtherAll = append(pb.RepOtherAll, testMessage)
+	pb.RepOtherAll = append(pb.RepOtherAll, testMessage)
+
+	errc := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func() {
+			s, err := MarshalPBLite(pb)
+			if err == nil && !bytes.Equal(s, []byte(pbLitePackageGolden)) {
+				err = fmt.Errorf("Found %s, want %s", string(s), pbLitePackageGolden)
+			}
+			errc <- err
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-errc; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+const (
+	personDescriptor = `
+		name: "person.proto"
+		message_type: {
+			name: "Person"
+			field: {name: "id" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32}
+			field: {name: "name" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING}
+			field: {name: "email" number: 3 label: LABEL_OPTIONAL type: TYPE_STRING}
+			field: {
+				name: "friends" number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE
+				type_name: ".Person"
+			}
+		}`
+
+	personPBLiteGolden = "[null,1,null,\"user@example.com\",[[null,2]]]"
+	personObjectGolden = "{\"email\":\"user@example.com\",\"friends\":[{\"id\":2}],\"id\":1}"
+
+	pbLiteProto3Golden = "[null," +
+		"101," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"\"test\"," +
+		"\"abcd\"," +
+		"null," +
+		"null," +
+		"[]," +
+		"null," +
+		"null," +
+		"2," +
+		"0," +
+		"\"\"," +
+		"0," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"null," +
+		"[201,0]" +
+		"]"
+
+	objectKeyNameProto3Golden = "{" +
+		"\"explicit_bool\":false," +
+		"\"explicit_int32\":0," +
+		"\"explicit_string\":\"\"," +
+		"\"optional_bytes\":\"abcd\"," +
+		"\"optional_int32\":101," +
+		"\"optional_nested_enum\":2," +
+		"\"optional_nested_message\":{}," +
+		"\"optional_string\":\"test\"," +
+		"\"repeated_int32\":[201,0]" +
+		"}"
+
+	objectKeyNameProto3ZeroGolden = "{" +
+		"\"optional_int32\":0," +
+		"\"optional_string\":\"\"," +
+		"\"optional_bool\":false" +
+		"}"
+
+	fieldDescriptorPBLiteGolden = "[null,\"foo\",null,1,1,5]"
+	fieldDescriptorObjectGolden = "{\"label\":1,\"name\":\"foo\",\"number\":1,\"type\":5}"
+)
+
+// messageDescriptor builds the descriptor of the message name in the file
+// descriptor written in text format.
+func messageDescriptor(t *testing.T, text string, name protoreflect.Name) protoreflect.MessageDescriptor {
+	fdp := &descriptorpb.FileDescriptorProto{}
+	err := prototext.Unmarshal([]byte(text), fdp)
+	if err != nil {
+		t.Fatalf("unable to parse descriptor: %v", err)
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatalf("unable to build descriptor: %v", err)
+	}
+	return fd.Messages().ByName(name)
+}
+
+func personMessageDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	return messageDescriptor(t, personDescriptor, "Person")
+}
+
+func validatePerson(t *testing.T, pb *dynamicpb.Message) {
+	fields := pb.Descriptor().Fields()
+	if id := pb.Get(fields.ByName("id")).Int(); id != 1 {
+		t.Errorf("Found %d, want 1 (id)", id)
+	}
+	if pb.Has(fields.ByName("name")) {
+		t.Errorf("Field not expected, name")
+	}
+	email := pb.Get(fields.ByName("email")).String()
+	if email != "user@example.com" {
+		t.Errorf("Found %s, want 'user@example.com' (email)", email)
+	}
+	friends := pb.Get(fields.ByName("friends")).List()
+	if friends.Len() != 1 {
+		t.Fatalf("Found len %d, want 1 (friends)", friends.Len())
+	}
+	friendID := friends.Get(0).Message().Get(fields.ByName("id")).Int()
+	if friendID != 2 {
+		t.Errorf("Found %d, want 2 (friends[0].id)", friendID)
+	}
+}
+
+func TestDynamicMessage(t *testing.T) {
+	md := personMessageDescriptor(t)
+
+	pb := dynamicpb.NewMessage(md)
+	err := UnmarshalPBLite([]byte(personPBLiteGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	validatePerson(t, pb)
+
+	s, err := MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(personObjectGolden)) {
+		t.Errorf("Found %s, want %s", string(s), personObjectGolden)
+	}
+
+	pb = dynamicpb.NewMessage(md)
+	err = UnmarshalObjectKeyName(s, pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	validatePerson(t, pb)
+
+	s, err = MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	if !bytes.Equal(s, []byte(personPBLiteGolden)) {
+		t.Errorf("Found %s, want %s", string(s), personPBLiteGolden)
+	}
+}
+
+func TestMessageInfoCache(t *testing.T) {
+	// descriptors built at runtime are not cached
+	md := personMessageDescriptor(t)
+	_, err := MarshalPBLite(dynamicpb.NewMessage(md))
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	messageInfoMu.RLock()
+	_, ok := messageInfoCache[md]
+	messageInfoMu.RUnlock()
+	if ok {
+		t.Errorf("Found cached %v, want not cached", md.FullName())
+	}
+
+	pb := &test_pb.TestAllTypes{}
+	_, err = MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	md = pb.ProtoReflect().Descriptor()
+	messageInfoMu.RLock()
+	_, ok = messageInfoCache[md]
+	messageInfoMu.RUnlock()
+	if !ok {
+		t.Errorf("Found %v not cached, want cached", md.FullName())
+	}
+}
+
+func TestGeneratedV2Message(t *testing.T) {
+	pb := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String("foo"),
+		Number: proto.Int32(1),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+	}
+
+	s, err := MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	if !bytes.Equal(s, []byte(fieldDescriptorPBLiteGolden)) {
+		t.Errorf("Found %s, want %s", string(s), fieldDescriptorPBLiteGolden)
+	}
+	got := &descriptorpb.FieldDescriptorProto{}
+	err = UnmarshalPBLite(s, got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	s, err = MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(fieldDescriptorObjectGolden)) {
+		t.Errorf("Found %s, want %s", string(s), fieldDescriptorObjectGolden)
+	}
+	got = &descriptorpb.FieldDescriptorProto{}
+	err = UnmarshalObjectKeyName(s, got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+}
+
+func populateProto3Message(pb *test3_pb.TestProto3Types) {
+	pb.OptionalInt32 = 101
+	pb.OptionalString = "test"
+	pb.OptionalBytes = []byte("abcd")
+	pb.OptionalNestedMessage = &test3_pb.TestProto3Types_NestedMessage{}
+	pb.OptionalNestedEnum = test3_pb.TestProto3Types_BAR
+
+	// zero values with explicit presence are still written
+	pb.ExplicitInt32 = proto.Int32(0)
+	pb.ExplicitString = proto.String("")
+	pb.ExplicitBool = proto.Bool(false)
+
+	pb.RepeatedInt32 = append(pb.RepeatedInt32, 201)
+	pb.RepeatedInt32 = append(pb.RepeatedInt32, 0)
+}
+
+func validateProto3Message(t *testing.T, pb *test3_pb.TestProto3Types) {
+	if pb.OptionalInt32 != 101 {
+		t.Errorf("Found %d, want 101 (OptionalInt32)", pb.OptionalInt32)
+	}
+	if pb.OptionalInt64 != 0 {
+		t.Errorf("Found %d, want 0 (OptionalInt64)", pb.OptionalInt64)
+	}
+	if pb.OptionalString != "test" {
+		t.Errorf("Found %s, want 'test' (OptionalString)", pb.OptionalString)
+	}
+	if !bytes.Equal(pb.OptionalBytes, []byte("abcd")) {
+		t.Errorf("Found %s, want 'abcd' (OptionalBytes)", pb.OptionalBytes)
+	}
+	if pb.OptionalNestedMessage == nil {
+		t.Errorf("Field expected, OptionalNestedMessage")
+	}
+	if pb.OptionalNestedEnum != test3_pb.TestProto3Types_BAR {
+		t.Errorf("Found %d, want BAR (OptionalNestedEnum)", pb.OptionalNestedEnum)
+	}
+	if pb.ExplicitInt32 == nil || *pb.ExplicitInt32 != 0 {
+		t.Errorf("Found %v, want 0 (ExplicitInt32)", pb.ExplicitInt32)
+	}
+	if pb.ExplicitString == nil || *pb.ExplicitString != "" {
+		t.Errorf("Found %v, want '' (ExplicitString)", pb.ExplicitString)
+	}
+	if pb.ExplicitBool == nil || *pb.ExplicitBool {
+		t.Errorf("Found %v, want false (ExplicitBool)", pb.ExplicitBool)
+	}
+	if pb.ExplicitNestedEnum != nil {
+		t.Errorf("Field not expected, ExplicitNestedEnum")
+	}
+	if len(pb.RepeatedInt32) != 2 {
+		t.Fatalf("Found len %d, want 2 (RepeatedInt32)", len(pb.RepeatedInt32))
+	}
+	if pb.RepeatedInt32[0] != 201 || pb.RepeatedInt32[1] != 0 {
+		t.Errorf("Found %v, want [201 0] (RepeatedInt32)", pb.RepeatedInt32)
+	}
+}
+
+func TestMarshalPBLiteProto3(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	populateProto3Message(pb)
+
+	s, err := MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteProto3Golden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteProto3Golden)
+	}
+}
+
+func TestUnmarshalPBLiteProto3(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalPBLite([]byte(pbLiteProto3Golden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	validateProto3Message(t, pb)
+}
+
+func TestMarshalObjectKeyNameProto3(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	populateProto3Message(pb)
+
+	s, err := MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameProto3Golden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameProto3Golden)
+	}
+}
+
+func TestUnmarshalObjectKeyNameProto3(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalObjectKeyName([]byte(objectKeyNameProto3Golden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	validateProto3Message(t, pb)
+}
+
+func TestUnmarshalObjectKeyNameProto3ZeroValues(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalObjectKeyName([]byte(objectKeyNameProto3ZeroGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if pb.OptionalInt32 != 0 || pb.OptionalString != "" || pb.OptionalBool {
+		t.Errorf("Found %v, want zero values", pb)
+	}
+
+	s, err := MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if string(s) != "{}" {
+		t.Errorf("Found %s, want {}", string(s))
+	}
+}
+
+var (
+	// the oneof member with tag 111 is written at its own array position
+	pbLiteOneofGolden = "[" +
+		strings.Repeat("null,", 31) +
+		strings.Repeat("[],", 15) +
+		"null,null," +
+		"[],[]," +
+		"null,null," +
+		"[],[]," +
+		"[],[],[],[]," +
+		strings.Repeat("null,", 53) +
+		"0" +
+		"]"
+
+	pbLiteMapGolden = "[" +
+		strings.Repeat("null,", 31) +
+		strings.Repeat("[],", 15) +
+		"null,null," +
+		"[],[]," +
+		"null,null," +
+		"[],[]," +
+		"[[null,-1,0],[null,1,2]]," +
+		"[[null,\"" + oobJSStr + "\",\"1\"]]," +
+		"[[null,\"a\",\"1\"],[null,\"b\",\"2\"]]," +
+		"[[null,\"x\",[null,1]]]" +
+		"]"
+
+	objectKeyNameMapGolden = "{" +
+		"\"map_int32_int32\":{\"-1\":0,\"1\":2}," +
+		"\"map_int64_int64\":{\"" + oobJSStr + "\":\"1\"}," +
+		"\"map_string_nested_message\":{\"x\":{\"b\":1}}," +
+		"\"map_string_string\":{\"a\":\"1\",\"b\":\"2\"}" +
+		"}"
+
+	pbLiteExtensionGolden = "[null," +
+		"1," +
+		strings.Repeat("null,", 8) +
+		"5," +
+		"\"" + oobJSStr + "\"," +
+		"[null,2]," +
+		"[\"a\",\"b\"]" +
+		"]"
+
+	objectKeyNameExtensionGolden = "{" +
+		"\"extensiontest.optional_int32_extension\":5," +
+		"\"extensiontest.optional_int64_extension\":\"" + oobJSStr + "\"," +
+		"\"extensiontest.optional_message_extension\":{\"optional_int32\":2}," +
+		"\"extensiontest.repeated_string_extension\":[\"a\",\"b\"]," +
+		"\"optional_int32\":1" +
+		"}"
+
+	objectKeyTagExtensionGolden = "{" +
+		"\"1\":1," +
+		"\"10\":5," +
+		"\"11\":\"" + oobJSStr + "\"," +
+		"\"12\":{\"1\":2}," +
+		"\"13\":[\"a\",\"b\"]" +
+		"}"
+
+	objectKeyNameOneofGolden = "{\"oneof_nested_message\":{\"b\":1}}"
+	objectKeyTagOneofGolden  = "{\"112\":{\"1\":1}}"
+)
+
+func TestMarshalPBLiteOneof(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	pb.OneofField = &test3_pb.TestProto3Types_OneofUint32{OneofUint32: 0}
+
+	s, err := MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteOneofGolden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteOneofGolden)
+	}
+}
+
+func TestUnmarshalPBLiteOneof(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalPBLite([]byte(pbLiteOneofGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	_, ok := pb.OneofField.(*test3_pb.TestProto3Types_OneofUint32)
+	if !ok {
+		t.Errorf("Found %T, want OneofUint32 (OneofField)", pb.OneofField)
+	}
+}
+
+func TestMarshalObjectOneof(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	nestedMessage := &test3_pb.TestProto3Types_NestedMessage{B: 1}
+	pb.OneofField = &test3_pb.TestProto3Types_OneofNestedMessage{
+		OneofNestedMessage: nestedMessage,
+	}
+
+	s, err := MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameOneofGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameOneofGolden)
+	}
+
+	s, err = MarshalObjectKeyTag(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyTag: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyTagOneofGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyTagOneofGolden)
+	}
+}
+
+func TestUnmarshalObjectOneof(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalObjectKeyTag([]byte(objectKeyTagOneofGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyTag: %v", err)
+	}
+	if pb.GetOneofNestedMessage().GetB() != 1 {
+		t.Errorf("Found %v, want OneofNestedMessage.B = 1", pb.OneofField)
+	}
+}
+
+func TestUnmarshalOneofConflict(t *testing.T) {
+	data := "{\"oneof_uint32\":1,\"oneof_string\":\"foo\"}"
+	err := UnmarshalObjectKeyName([]byte(data), &test3_pb.TestProto3Types{})
+	if err == nil {
+		t.Errorf("Expected error for multiple oneof members")
+	}
+
+	data = "[" + strings.Repeat("null,", 111) + "1,null,\"foo\"]"
+	err = UnmarshalPBLite([]byte(data), &test3_pb.TestProto3Types{})
+	if err == nil {
+		t.Errorf("Expected error for multiple oneof members")
+	}
+
+	// null members are absent and do not conflict
+	data = "{\"oneof_uint32\":1,\"oneof_string\":null}"
+	err = UnmarshalObjectKeyName([]byte(data), &test3_pb.TestProto3Types{})
+	if err != nil {
+		t.Errorf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+}
+
+func populateMapMessage(pb *test3_pb.TestProto3Types) {
+	pb.MapInt32Int32 = map[int32]int32{1: 2, -1: 0}
+	pb.MapInt64Int64 = map[int64]int64{oobJSInt: 1}
+	pb.MapStringString = map[string]string{"b": "2", "a": "1"}
+	pb.MapStringNestedMessage = map[string]*test3_pb.TestProto3Types_NestedMessage{
+		"x": {B: 1},
+	}
+}
+
+func TestMarshalPBLiteMap(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	populateMapMessage(pb)
+
+	s, err := MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteMapGolden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteMapGolden)
+	}
+}
+
+func TestUnmarshalPBLiteMap(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalPBLite([]byte(pbLiteMapGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	want := &test3_pb.TestProto3Types{}
+	populateMapMessage(want)
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+}
+
+func TestPBLiteMapZeroIndex(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	pb.MapStringNestedMessage = map[string]*test3_pb.TestProto3Types_NestedMessage{
+		"x": {B: 1},
+	}
+
+	m := &PBLiteMarshaler{ZeroIndex: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	u := &PBLiteUnmarshaler{ZeroIndex: true}
+	got := &test3_pb.TestProto3Types{}
+	err = u.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+}
+
+func TestMarshalObjectKeyNameMap(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	populateMapMessage(pb)
+
+	s, err := MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameMapGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameMapGolden)
+	}
+}
+
+func TestUnmarshalObjectKeyNameMap(t *testing.T) {
+	pb := &test3_pb.TestProto3Types{}
+	err := UnmarshalObjectKeyName([]byte(objectKeyNameMapGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	want := &test3_pb.TestProto3Types{}
+	populateMapMessage(want)
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+}
+
+func TestUnmarshalObjectMapBadKey(t *testing.T) {
+	data := "{\"map_int32_int32\":{\"foo\":1}}"
+	err := UnmarshalObjectKeyName([]byte(data), &test3_pb.TestProto3Types{})
+	if err == nil {
+		t.Errorf("Expected error for non-integer map key")
+	}
+}
+
+func populateExtensionMessage(t *testing.T, pb *extension_test_pb.TestExtendable) {
+	pb.OptionalInt32 = proto.Int32(1)
+	extensions := []struct {
+		desc  *proto.ExtensionDesc
+		value interface{}
+	}{
+		{extension_test_pb.E_OptionalInt32Extension, proto.Int32(5)},
+		{extension_test_pb.E_OptionalInt64Extension, proto.Int64(oobJSInt)},
+		{extension_test_pb.E_OptionalMessageExtension,
+			&extension_test_pb.TestExtendable{OptionalInt32: proto.Int32(2)}},
+		{extension_test_pb.E_RepeatedStringExtension, []string{"a", "b"}},
+	}
+	for _, e := range extensions {
+		err := proto.SetExtension(pb, e.desc, e.value)
+		if err != nil {
+			t.Fatalf("unable to SetExtension: %v", err)
+		}
+	}
+}
+
+func TestMarshalExtension(t *testing.T) {
+	pb := &extension_test_pb.TestExtendable{}
+	populateExtensionMessage(t, pb)
+
+	goldens := []struct {
+		marshal func(proto.Message) ([]byte, error)
+		golden  string
+	}{
+		{MarshalPBLite, pbLiteExtensionGolden},
+		{MarshalObjectKeyName, objectKeyNameExtensionGolden},
+		{MarshalObjectKeyTag, objectKeyTagExtensionGolden},
+	}
+	for _, g := range goldens {
+		s, err := g.marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal: %v", err)
+		}
+		if !bytes.Equal(s, []byte(g.golden)) {
+			t.Errorf("Found %s, want %s", string(s), g.golden)
+		}
+	}
+}
+
+func TestUnmarshalExtension(t *testing.T) {
+	want := &extension_test_pb.TestExtendable{}
+	populateExtensionMessage(t, want)
+
+	goldens := []struct {
+		unmarshal func([]byte, proto.Message) error
+		golden    string
+	}{
+		{UnmarshalPBLite, pbLiteExtensionGolden},
+		{UnmarshalObjectKeyName, objectKeyNameExtensionGolden},
+		{UnmarshalObjectKeyTag, objectKeyTagExtensionGolden},
+	}
+	for _, g := range goldens {
+		pb := &extension_test_pb.TestExtendable{}
+		err := g.unmarshal([]byte(g.golden), pb)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal: %v", err)
+		}
+		if !proto.Equal(pb, want) {
+			t.Errorf("Found %v, want %v", pb, want)
+		}
+	}
+}
+
+func TestPBLiteUnknownRoundTrip(t *testing.T) {
+	data := "[null,1,null,[],{\"a\":1},\"str\",[1,\"2\"]]"
+
+	pb := &package_test_pb.TestPackageTypes{}
+	u := &PBLiteUnmarshaler{KeepUnknown: true}
+	err := u.Unmarshal([]byte(data), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	m := &PBLiteMarshaler{EmitUnknown: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if string(s) != data {
+		t.Errorf("Found %s, want %s", string(s), data)
+	}
+
+	// unknown positions are written under their tag numbers in PBObject
+	om := &PBObjectMarshaler{KeyTag: true, EmitUnknown: true}
+	s, err = om.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	want := "{\"1\":1,\"4\":{\"a\":1},\"5\":\"str\",\"6\":[1,\"2\"]}"
+	if string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+
+	// unknown fields are discarded by default
+	pb = &package_test_pb.TestPackageTypes{}
+	err = UnmarshalPBLite([]byte(data), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	if len(pb.XXX_unrecognized) != 0 {
+		t.Errorf("Found unknown fields %v, want none", pb.XXX_unrecognized)
+	}
+}
+
+func TestPBObjectUnknownRoundTrip(t *testing.T) {
+	tests := []struct {
+		keyTag bool
+		data   string
+	}{
+		{false, "{\"optinal_int32\":[1,2],\"optional_int32\":1,\"7\":\"x\"}"},
+		{true, "{\"1\":1,\"7\":\"x\",\"foo\":true}"},
+	}
+	for _, test := range tests {
+		pb := &package_test_pb.TestPackageTypes{}
+		u := &PBObjectUnmarshaler{KeyTag: test.keyTag, KeepUnknown: true}
+		err := u.Unmarshal([]byte(test.data), pb)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal: %v", err)
+		}
+		if pb.OptionalInt32 == nil || *pb.OptionalInt32 != 1 {
+			t.Errorf("Found %v, want 1 (OptionalInt32)", pb.OptionalInt32)
+		}
+
+		m := &PBObjectMarshaler{KeyTag: test.keyTag, EmitUnknown: true}
+		s, err := m.Marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal: %v", err)
+		}
+		var got, want interface{}
+		json.Unmarshal(s, &got)
+		json.Unmarshal([]byte(test.data), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Found %s, want %s", string(s), test.data)
+		}
+	}
+}
+
+func TestUnknownBinaryRoundTrip(t *testing.T) {
+	data := "{\"14\":\"hello\",\"99\":[1,2],\"foo\":{\"a\":true}}"
+
+	pb := &test_pb.TestAllTypes_NestedMessage{}
+	u := &PBObjectUnmarshaler{KeyTag: true, KeepUnknown: true}
+	err := u.Unmarshal([]byte(data), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	b, err := proto.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+
+	// readers that know tag 14 do not see the unknown JSON value
+	other := &test_pb.TestAllTypes{}
+	err = proto.Unmarshal(b, other)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if other.OptionalString != nil {
+		t.Errorf("Found %q, want nil (OptionalString)", *other.OptionalString)
+	}
+
+	// but the JSON entries survive the binary round trip
+	pb = &test_pb.TestAllTypes_NestedMessage{}
+	err = proto.Unmarshal(b, pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	m := &PBObjectMarshaler{KeyTag: true, EmitUnknown: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if string(s) != data {
+		t.Errorf("Found %s, want %s", string(s), data)
+	}
+
+	// decoding again replaces the entries with the same keys
+	err = u.Unmarshal([]byte("{\"99\":3}"), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	s, err = m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	want := "{\"14\":\"hello\",\"99\":3,\"foo\":{\"a\":true}}"
+	if string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+}
+
+func TestPBLiteEmitBinaryUnknown(t *testing.T) {
+	// optional_int32 = 1 followed by unknown varint field 5 = 150
+	pb := &package_test_pb.TestPackageTypes{}
+	err := proto.Unmarshal([]byte{0x08, 0x01, 0x28, 0x96, 0x01}, pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+
+	m := &PBLiteMarshaler{EmitUnknown: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	want := "[null,1,null,[],null,\"150\"]"
+	if string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+
+	// 64-bit values are written as decimal strings
+	b := protowire.AppendTag(nil, 5, protowire.VarintType)
+	b = protowire.AppendVarint(b, math.MaxUint64)
+	b = protowire.AppendTag(b, 6, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.MaxUint64)
+	pb = &package_test_pb.TestPackageTypes{}
+	err = proto.Unmarshal(b, pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	s, err = m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	want = "[null,null,null,[],null,\"18446744073709551615\",\"18446744073709551615\"]"
+	if string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+}
+
+func TestPBLiteEmitFarUnknown(t *testing.T) {
+	// unknown fields far beyond the known tags do not grow the array
+	b := protowire.AppendTag(nil, 20000000, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	pb := &package_test_pb.TestPackageTypes{}
+	err := proto.Unmarshal(b, pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	m := &PBLiteMarshaler{EmitUnknown: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if want := "[]"; string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+
+	pb = &package_test_pb.TestPackageTypes{}
+	u := &PBObjectUnmarshaler{KeyTag: true, KeepUnknown: true}
+	err = u.Unmarshal([]byte("{\"20000000\":1,\"1000\":2}"), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	s, err = m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	want := "[null,null,null,[]" + strings.Repeat(",null", 996) + ",2]"
+	if string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+
+	// the Object format still writes them
+	om := &PBObjectMarshaler{KeyTag: true, EmitUnknown: true}
+	s, err = om.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if want := "{\"1000\":2,\"20000000\":1}"; string(s) != want {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+}
+
+func TestPBObjectDisallowUnknown(t *testing.T) {
+	u := &PBObjectUnmarshaler{DisallowUnknown: true}
+	err := u.Unmarshal([]byte(objectKeyNamePackageGolden),
+		&package_test_pb.TestPackageTypes{})
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+
+	data := "{\"optinal_int32\":1,\"other_all\":{\"optional_bool\":true,\"foo\":null}}"
+	err = u.Unmarshal([]byte(data), &package_test_pb.TestPackageTypes{})
+	if err == nil || !strings.Contains(err.Error(), "optinal_int32") {
+		t.Errorf("Found %v, want error naming optinal_int32", err)
+	}
+
+	data = "{\"other_all\":{\"optional_bool\":true,\"foo\":null}}"
+	err = u.Unmarshal([]byte(data), &package_test_pb.TestPackageTypes{})
+	if err == nil || !strings.Contains(err.Error(), "foo") {
+		t.Errorf("Found %v, want error naming foo", err)
+	}
+
+	u = &PBObjectUnmarshaler{KeyTag: true, DisallowUnknown: true}
+	err = u.Unmarshal([]byte("{\"1\":1,\"9\":2}"),
+		&package_test_pb.TestPackageTypes{})
+	if err == nil || !strings.Contains(err.Error(), "9") {
+		t.Errorf("Found %v, want error naming 9", err)
+	}
+
+	// unknown keys of nested messages are reported with their path
+	u = &PBObjectUnmarshaler{DisallowUnknown: true}
+	err = u.Unmarshal([]byte("{\"repeated_nested_message\":[{},{\"b\":1,\"zz\":2}]}"),
+		&test_pb.TestAllTypes{})
+	var uerr *UnknownFieldError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("Found %v, want *UnknownFieldError", err)
+	}
+	want := "Unknown fields at repeated_nested_message[1] in " +
+		"protoclosure.test_pb.TestAllTypes: zz"
+	if err.Error() != want {
+		t.Errorf("Found %s, want %s", err.Error(), want)
+	}
+	if !reflect.DeepEqual(uerr.Keys, []string{"zz"}) {
+		t.Errorf("Found %v, want [zz]", uerr.Keys)
+	}
+}
+
+func TestPBLiteDisallowUnknown(t *testing.T) {
+	u := &PBLiteUnmarshaler{DisallowUnknown: true}
+	err := u.Unmarshal([]byte(pbLitePackageGolden),
+		&package_test_pb.TestPackageTypes{})
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+
+	// null values at unknown indices are allowed
+	err = u.Unmarshal([]byte("[null,1,null,[],null,null]"),
+		&package_test_pb.TestPackageTypes{})
+	if err != nil {
+		t.Errorf("unable to Unmarshal: %v", err)
+	}
+
+	err = u.Unmarshal([]byte("[null,1,null,[],null,2,null,3]"),
+		&package_test_pb.TestPackageTypes{})
+	if err == nil || !strings.Contains(err.Error(), "5, 7") {
+		t.Errorf("Found %v, want error naming indices 5, 7", err)
+	}
+
+	err = u.Unmarshal([]byte("["+strings.Repeat("null,", 18)+"[null,1,2,3]]"),
+		&test_pb.TestAllTypes{})
+	var uerr *UnknownFieldError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("Found %v, want *UnknownFieldError", err)
+	}
+	want := "Unknown indices at optional_nested_message in " +
+		"protoclosure.test_pb.TestAllTypes: 3"
+	if err.Error() != want {
+		t.Errorf("Found %s, want %s", err.Error(), want)
+	}
+}
+
+const (
+	requiredPBLiteGolden = "[null,1,null,[null,null,\"x\"]," +
+		"[[null,2,\"y\"],[null,null,\"z\"]],[[null,\"k\",[]]]]"
+	requiredObjectGolden = "{\"a\":1,\"map_message\":{\"k\":{}}," +
+		"\"optional_message\":{\"b\":\"x\"}," +
+		"\"repeated_message\":[{\"a\":2,\"b\":\"y\"},{\"b\":\"z\"}]}"
+)
+
+var requiredMissingFields = []string{
+	"b",
+	"optional_message.a",
+	"repeated_message[1].a",
+	"map_message[k].a",
+	"map_message[k].b",
+}
+
+func requiredMessage() *required_test_pb.TestRequired {
+	return &required_test_pb.TestRequired{
+		A:               proto.Int32(1),
+		OptionalMessage: &required_test_pb.TestRequired{B: proto.String("x")},
+		RepeatedMessage: []*required_test_pb.TestRequired{
+			{A: proto.Int32(2), B: proto.String("y")},
+			{B: proto.String("z")},
+		},
+		MapMessage: map[string]*required_test_pb.TestRequired{
+			"k": {},
+		},
+	}
+}
+
+func validateRequiredNotSet(t *testing.T, err error) {
+	var rerr *RequiredNotSetError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Found %v, want *RequiredNotSetError", err)
+	}
+	if !reflect.DeepEqual(rerr.Fields, requiredMissingFields) {
+		t.Errorf("Found %v, want %v", rerr.Fields, requiredMissingFields)
+	}
+}
+
+func TestMarshalCheckRequired(t *testing.T) {
+	pb := requiredMessage()
+
+	s, err := MarshalPBLite(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalPBLite: %v", err)
+	}
+	if !bytes.Equal(s, []byte(requiredPBLiteGolden)) {
+		t.Errorf("Found %s, want %s", string(s), requiredPBLiteGolden)
+	}
+
+	lm := &PBLiteMarshaler{CheckRequired: true}
+	s, err = lm.Marshal(pb)
+	validateRequiredNotSet(t, err)
+	if !bytes.Equal(s, []byte(requiredPBLiteGolden)) {
+		t.Errorf("Found %s, want %s", string(s), requiredPBLiteGolden)
+	}
+
+	om := &PBObjectMarshaler{CheckRequired: true}
+	s, err = om.Marshal(pb)
+	validateRequiredNotSet(t, err)
+	if !bytes.Equal(s, []byte(requiredObjectGolden)) {
+		t.Errorf("Found %s, want %s", string(s), requiredObjectGolden)
+	}
+
+	pb.B = proto.String("b")
+	pb.OptionalMessage.A = proto.Int32(3)
+	pb.RepeatedMessage[1].A = proto.Int32(4)
+	pb.MapMessage["k"].A = proto.Int32(5)
+	pb.MapMessage["k"].B = proto.String("v")
+	_, err = om.Marshal(pb)
+	if err != nil {
+		t.Errorf("unable to Marshal: %v", err)
+	}
+}
+
+func TestUnmarshalCheckRequired(t *testing.T) {
+	pb := &required_test_pb.TestRequired{}
+	lu := &PBLiteUnmarshaler{CheckRequired: true}
+	err := lu.Unmarshal([]byte(requiredPBLiteGolden), pb)
+	validateRequiredNotSet(t, err)
+	if !proto.Equal(pb, requiredMessage()) {
+		t.Errorf("Found %v, want %v", pb, requiredMessage())
+	}
+
+	pb = &required_test_pb.TestRequired{}
+	ou := &PBObjectUnmarshaler{CheckRequired: true}
+	err = ou.Unmarshal([]byte(requiredObjectGolden), pb)
+	validateRequiredNotSet(t, err)
+	if !proto.Equal(pb, requiredMessage()) {
+		t.Errorf("Found %v, want %v", pb, requiredMessage())
+	}
+
+	// required fields are not checked by default
+	err = UnmarshalObjectKeyName([]byte(requiredObjectGolden),
+		&required_test_pb.TestRequired{})
+	if err != nil {
+		t.Errorf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+}
+
+// unmarshaler is implemented by PBLiteUnmarshaler and PBObjectUnmarshaler.
+type unmarshaler interface {
+	Unmarshal(data []byte, pb proto.Message) error
+}
+
+type marshaler interface {
+	Marshal(pb proto.Message) ([]byte, error)
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		data  string
+		u     unmarshaler
+		want  DecodeError
+		cause error
+	}{
+		{
+			"{\"repeated_nested_message\":[{},{},{},{\"b\":\"x\"}]}",
+			&PBObjectUnmarshaler{},
+			DecodeError{
+				MessageType: "protoclosure.test_pb.TestAllTypes",
+				Path:        "repeated_nested_message[3].b",
+				Tag:         1,
+				Value:       "x",
+				Expected:    "int32",
+			},
+			nil,
+		},
+		{
+			"{\"32\":[1,\"2\",\"x\"]}",
+			&PBObjectUnmarshaler{KeyTag: true},
+			DecodeError{
+				MessageType: "protoclosure.test_pb.TestAllTypes",
+				Path:        "repeated_int64[2]",
+				Tag:         32,
+				Value:       "x",
+				Expected:    "int64",
+			},
+			strconv.ErrSyntax,
+		},
+		{
+			"[null,null,null,null,null,null,null,null,null,null,null,null," +
+				"null,null,null,null,null,null,[null,true]]",
+			&PBLiteUnmarshaler{},
+			DecodeError{
+				MessageType: "protoclosure.test_pb.TestAllTypes",
+				Path:        "optional_nested_message.b",
+				Tag:         1,
+				Value:       true,
+				Expected:    "int32",
+			},
+			nil,
+		},
+		{
+			"[null,null,null,null,null,null,null,null,null,null,null,null," +
+				"null,null,null,null,null,null,7]",
+			&PBLiteUnmarshaler{},
+			DecodeError{
+				MessageType: "protoclosure.test_pb.TestAllTypes",
+				Path:        "optional_nested_message",
+				Tag:         18,
+				Value:       json.Number("7"),
+				Expected:    "protoclosure.test_pb.TestAllTypes.NestedMessage",
+			},
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.u.Unmarshal([]byte(test.data), &test_pb.TestAllTypes{})
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Errorf("Found %v, want *DecodeError", err)
+			continue
+		}
+		if test.cause != nil && !errors.Is(err, test.cause) {
+			t.Errorf("Found %v, want cause %v", de.Err, test.cause)
+		}
+		de.Err = nil
+		if !reflect.DeepEqual(*de, test.want) {
+			t.Errorf("Found %+v, want %+v", *de, test.want)
+		}
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	data := "{\"optional_int32\":\"a\",\"optional_string\":\"ok\"," +
+		"\"optional_nested_message\":{\"b\":true,\"c\":2}," +
+		"\"repeated_int64\":[1,\"x\",3]," +
+		"\"repeated_nested_message\":[{\"b\":1},5]}"
+	paths := []string{
+		"optional_int32",
+		"optional_nested_message.b",
+		"repeated_int64[1]",
+		"repeated_nested_message[1]",
+	}
+	want := &test_pb.TestAllTypes{
+		OptionalString: proto.String("ok"),
+		OptionalNestedMessage: &test_pb.TestAllTypes_NestedMessage{
+			C: proto.Int32(2),
+		},
+		RepeatedInt64: []int64{1, 3},
+		RepeatedNestedMessage: []*test_pb.TestAllTypes_NestedMessage{
+			{B: proto.Int32(1)},
+		},
+	}
+
+	pb := &test_pb.TestAllTypes{}
+	u := &PBObjectUnmarshaler{AllErrors: true}
+	err := u.Unmarshal([]byte(data), pb)
+	errs, ok := err.(DecodeErrors)
+	if !ok {
+		t.Fatalf("Found %v, want DecodeErrors", err)
+	}
+	var found []string
+	for _, err := range errs {
+		found = append(found, err.(*DecodeError).Path)
+	}
+	if !reflect.DeepEqual(found, paths) {
+		t.Errorf("Found %v, want %v", found, paths)
+	}
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Path != paths[0] {
+		t.Errorf("Found %v, want *DecodeError for %s", de, paths[0])
+	}
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+
+	// only the first error is returned by default
+	err = UnmarshalObjectKeyName([]byte(data), &test_pb.TestAllTypes{})
+	if !errors.As(err, &de) || de.Path != paths[0] {
+		t.Errorf("Found %v, want *DecodeError for %s", err, paths[0])
+	}
+
+	pb = &test_pb.TestAllTypes{}
+	lu := &PBLiteUnmarshaler{AllErrors: true}
+	err = lu.Unmarshal([]byte("[null,\"a\",null,null,null,null,null,null,null,"+
+		"null,null,null,null,null,\"ok\",null,null,null,[null,true,2]]"), pb)
+	errs, ok = err.(DecodeErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Found %v, want 2 DecodeErrors", err)
+	}
+	want = &test_pb.TestAllTypes{
+		OptionalString: proto.String("ok"),
+		OptionalNestedMessage: &test_pb.TestAllTypes_NestedMessage{
+			C: proto.Int32(2),
+		},
+	}
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+}
+
+func TestUnmarshalLossyNumbers(t *testing.T) {
+	tests := []struct {
+		data  string
+		cause error
+		lossy *test_pb.TestAllTypes
+	}{
+		{"{\"optional_int32\":3.7}", errNotInteger,
+			&test_pb.TestAllTypes{OptionalInt32: proto.Int32(3)}},
+		{"{\"optional_int32\":1e12}", strconv.ErrRange, nil},
+		{"{\"optional_uint32\":-1}", strconv.ErrRange, nil},
+		{"{\"optional_sint32\":-2147483649}", strconv.ErrRange, nil},
+		{"{\"optional_fixed32\":4294967296}", strconv.ErrRange, nil},
+		{"{\"optional_sfixed32\":0.5}", errNotInteger,
+			&test_pb.TestAllTypes{OptionalSfixed32: proto.Int32(0)}},
+		{"{\"optional_nested_enum\":2.5}", errNotInteger,
+			&test_pb.TestAllTypes{
+				OptionalNestedEnum: test_pb.TestAllTypes_BAR.Enum(),
+			}},
+		{"{\"optional_uint64\":-1}", strconv.ErrRange, nil},
+		{"{\"optional_int64_number\":1e19}", strconv.ErrRange, nil},
+		{"{\"repeated_uint32\":[1,-1]}", strconv.ErrRange, nil},
+	}
+
+	// out of range values are wrapped as Go conversions do, which is
+	// platform dependent, so only fractional values are compared
+	for _, test := range tests {
+		err := UnmarshalObjectKeyName([]byte(test.data), &test_pb.TestAllTypes{})
+		if !errors.Is(err, test.cause) {
+			t.Errorf("%s: Found %v, want %v", test.data, err, test.cause)
+		}
+
+		pb := &test_pb.TestAllTypes{}
+		u := &PBObjectUnmarshaler{AllowLossyNumbers: true}
+		err = u.Unmarshal([]byte(test.data), pb)
+		if err != nil {
+			t.Errorf("%s: unable to Unmarshal: %v", test.data, err)
+		}
+		if test.lossy != nil && !proto.Equal(pb, test.lossy) {
+			t.Errorf("Found %v, want %v", pb, test.lossy)
+		}
+	}
+
+	data := "{\"optional_int32\":-2147483648,\"optional_sint32\":2147483647," +
+		"\"optional_uint32\":4294967295,\"optional_fixed32\":4294967295}"
+	want := &test_pb.TestAllTypes{
+		OptionalInt32:   proto.Int32(math.MinInt32),
+		OptionalSint32:  proto.Int32(math.MaxInt32),
+		OptionalUint32:  proto.Uint32(math.MaxUint32),
+		OptionalFixed32: proto.Uint32(math.MaxUint32),
+	}
+	pb := &test_pb.TestAllTypes{}
+	err := UnmarshalObjectKeyName([]byte(data), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+}
+
+func TestUnmarshalNumberPrecision(t *testing.T) {
+	tests := []struct {
+		data string
+		want *test_pb.TestAllTypes
+	}{
+		{"{\"optional_int64_number\":9007199254740993}",
+			&test_pb.TestAllTypes{OptionalInt64Number: proto.Int64(oobJSInt)}},
+		{"{\"optional_int64_number\":-9223372036854775808}",
+			&test_pb.TestAllTypes{
+				OptionalInt64Number: proto.Int64(math.MinInt64),
+			}},
+		{"{\"optional_uint64\":18446744073709551615}",
+			&test_pb.TestAllTypes{OptionalUint64: proto.Uint64(math.MaxUint64)}},
+		{"{\"optional_fixed64\":9223372036854775809}",
+			&test_pb.TestAllTypes{
+				OptionalFixed64: proto.Uint64(1<<63 + 1),
+			}},
+		{"{\"optional_int64_number\":1e3}",
+			&test_pb.TestAllTypes{OptionalInt64Number: proto.Int64(1000)}},
+	}
+	for _, test := range tests {
+		pb := &test_pb.TestAllTypes{}
+		err := UnmarshalObjectKeyName([]byte(test.data), pb)
+		if err != nil {
+			t.Errorf("%s: unable to UnmarshalObjectKeyName: %v", test.data, err)
+			continue
+		}
+		if !proto.Equal(pb, test.want) {
+			t.Errorf("Found %v, want %v", pb, test.want)
+		}
+	}
+
+	errTests := []struct {
+		data  string
+		cause error
+	}{
+		{"{\"optional_int64_number\":9223372036854775808}", strconv.ErrRange},
+		{"{\"optional_uint64\":18446744073709551616}", strconv.ErrRange},
+		{"{\"optional_int64_number\":9.007199254740993e15}", errInexact},
+		{"{\"optional_int64_number\":1e19}", strconv.ErrRange},
+	}
+	for _, test := range errTests {
+		err := UnmarshalObjectKeyName([]byte(test.data), &test_pb.TestAllTypes{})
+		if !errors.Is(err, test.cause) {
+			t.Errorf("%s: Found %v, want %v", test.data, err, test.cause)
+		}
+	}
+
+	pb := &test_pb.TestAllTypes{}
+	dec := NewPBLiteDecoder(strings.NewReader(
+		"[" + strings.Repeat("null,", 50) + "9007199254740993]"))
+	err := dec.Decode(pb)
+	if err != nil {
+		t.Fatalf("unable to Decode: %v", err)
+	}
+	if pb.GetOptionalInt64Number() != oobJSInt {
+		t.Errorf("Found %d, want %d", pb.GetOptionalInt64Number(), oobJSInt)
+	}
+}
+
+var (
+	// repeated 64-bit integers follow the same number/string rules as
+	// singular ones
+	pbLiteRepeatedInt64Golden = "[" +
+		strings.Repeat("null,", 31) +
+		"[],[],[],[\"" + oobJSStr + "\"]," +
+		strings.Repeat("[],", 12) +
+		"null,[],[],null,null," +
+		"[" + maxSafeJSStr + ",-1]," +
+		"[\"" + oobJSStr + "\",\"-1\"]" +
+		"]"
+	pbLiteZeroIndexRepeatedInt64Golden = "[" +
+		strings.Repeat("null,", 30) +
+		"[],[],[],[\"" + oobJSStr + "\"]," +
+		strings.Repeat("[],", 12) +
+		"null,[],[],null,null," +
+		"[" + maxSafeJSStr + ",-1]," +
+		"[\"" + oobJSStr + "\",\"-1\"]" +
+		"]"
+	objectKeyNameRepeatedInt64Golden = "{" +
+		"\"repeated_int64_number\":[" + maxSafeJSStr + ",-1]," +
+		"\"repeated_int64_string\":[\"" + oobJSStr + "\",\"-1\"]," +
+		"\"repeated_uint64\":[\"" + oobJSStr + "\"]" +
+		"}"
+	objectKeyTagRepeatedInt64Golden = "{" +
+		"\"34\":[\"" + oobJSStr + "\"]," +
+		"\"52\":[" + maxSafeJSStr + ",-1]," +
+		"\"53\":[\"" + oobJSStr + "\",\"-1\"]" +
+		"}"
+)
+
+func TestRepeatedInt64RoundTrip(t *testing.T) {
+	pb := &test_pb.TestAllTypes{
+		RepeatedUint64:      []uint64{oobJSInt},
+		RepeatedInt64Number: []int64{maxSafeJSInt, -1},
+		RepeatedInt64String: []int64{oobJSInt, -1},
+	}
+
+	tests := []struct {
+		name      string
+		marshal   func(proto.Message) ([]byte, error)
+		unmarshal func([]byte, proto.Message) error
+		golden    string
+	}{
+		{"PBLite", MarshalPBLite, UnmarshalPBLite,
+			pbLiteRepeatedInt64Golden},
+		{"PBLiteZeroIndex", MarshalPBLiteZeroIndex, UnmarshalPBLiteZeroIndex,
+			pbLiteZeroIndexRepeatedInt64Golden},
+		{"ObjectKeyName", MarshalObjectKeyName, UnmarshalObjectKeyName,
+			objectKeyNameRepeatedInt64Golden},
+		{"ObjectKeyTag", MarshalObjectKeyTag, UnmarshalObjectKeyTag,
+			objectKeyTagRepeatedInt64Golden},
+	}
+	for _, test := range tests {
+		s, err := test.marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal%s: %v", test.name, err)
+		}
+		if !bytes.Equal(s, []byte(test.golden)) {
+			t.Errorf("Found %s, want %s", string(s), test.golden)
+		}
+
+		got := &test_pb.TestAllTypes{}
+		err = test.unmarshal(s, got)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal%s: %v", test.name, err)
+		}
+		if !proto.Equal(got, pb) {
+			t.Errorf("Found %v, want %v", got, pb)
+		}
+	}
+}
+
+const (
+	// a legacy schema relying on the "_number" name suffix
+	legacyNumberDescriptor = `
+		name: "legacy_number.proto"
+		message_type: {
+			name: "Legacy"
+			field: {name: "id_number" number: 1 label: LABEL_OPTIONAL type: TYPE_INT64}
+			field: {name: "id_string" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64}
+			field: {
+				name: "ids_number" number: 3 label: LABEL_REPEATED type: TYPE_INT64
+			}
+			field: {
+				name: "big_number" number: 4 label: LABEL_OPTIONAL type: TYPE_UINT64
+				options: {jstype: JS_STRING}
+			}
+		}`
+
+	legacyNumberObjectGolden = "{\"big_number\":\"1\",\"id_number\":\"1\"," +
+		"\"id_string\":\"1\",\"ids_number\":[\"1\"]}"
+	legacyNumberSuffixObjectGolden = "{\"big_number\":\"1\",\"id_number\":1," +
+		"\"id_string\":\"1\",\"ids_number\":[1]}"
+	legacyNumberSuffixPBLiteGolden = "[null,1,\"1\",[1],\"1\"]"
+)
+
+func TestNumberSuffix(t *testing.T) {
+	md := messageDescriptor(t, legacyNumberDescriptor, "Legacy")
+	pb := dynamicpb.NewMessage(md)
+	fields := md.Fields()
+	pb.Set(fields.ByName("id_number"), protoreflect.ValueOfInt64(1))
+	pb.Set(fields.ByName("id_string"), protoreflect.ValueOfInt64(1))
+	ids := pb.Mutable(fields.ByName("ids_number")).List()
+	ids.Append(protoreflect.ValueOfInt64(1))
+	pb.Set(fields.ByName("big_number"), protoreflect.ValueOfUint64(1))
+
+	// the name suffix is ignored by default
+	s, err := MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(legacyNumberObjectGolden)) {
+		t.Errorf("Found %s, want %s", string(s), legacyNumberObjectGolden)
+	}
+
+	om := &PBObjectMarshaler{NumberSuffix: true}
+	s, err = om.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(legacyNumberSuffixObjectGolden)) {
+		t.Errorf("Found %s, want %s", string(s), legacyNumberSuffixObjectGolden)
+	}
+
+	lm := &PBLiteMarshaler{NumberSuffix: true}
+	s, err = lm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(legacyNumberSuffixPBLiteGolden)) {
+		t.Errorf("Found %s, want %s", string(s), legacyNumberSuffixPBLiteGolden)
+	}
+
+	got := dynamicpb.NewMessage(md)
+	err = UnmarshalPBLite(s, got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+}
+
+var (
+	// groups are written like nested messages, indexed by their own tags
+	pbLiteGroupGolden = "[" +
+		strings.Repeat("null,", 16) +
+		"[" + strings.Repeat("null,", 17) + "111]," +
+		strings.Repeat("null,", 14) +
+		strings.Repeat("[],", 15) +
+		"[[" + strings.Repeat("null,", 47) + "[1,2]],[]]" +
+		"]"
+	pbLiteZeroIndexGroupGolden = "[" +
+		strings.Repeat("null,", 15) +
+		"[" + strings.Repeat("null,", 16) + "111]," +
+		strings.Repeat("null,", 14) +
+		strings.Repeat("[],", 15) +
+		"[[" + strings.Repeat("null,", 46) + "[1,2]],[]]" +
+		"]"
+	objectKeyNameGroupGolden = "{\"optionalgroup\":{\"a\":111}," +
+		"\"repeatedgroup\":[{\"a\":[1,2]},{}]}"
+	objectKeyTagGroupGolden = "{\"16\":{\"17\":111},\"46\":[{\"47\":[1,2]},{}]}"
+)
+
+func TestGroupRoundTrip(t *testing.T) {
+	pb := &test_pb.TestAllTypes{
+		Optionalgroup: &test_pb.TestAllTypes_OptionalGroup{
+			A: proto.Int32(111),
+		},
+		Repeatedgroup: []*test_pb.TestAllTypes_RepeatedGroup{
+			{A: []int32{1, 2}},
+			{},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		marshal   func(proto.Message) ([]byte, error)
+		unmarshal func([]byte, proto.Message) error
+		golden    string
+	}{
+		{"PBLite", MarshalPBLite, UnmarshalPBLite, pbLiteGroupGolden},
+		{"PBLiteZeroIndex", MarshalPBLiteZeroIndex, UnmarshalPBLiteZeroIndex,
+			pbLiteZeroIndexGroupGolden},
+		{"ObjectKeyName", MarshalObjectKeyName, UnmarshalObjectKeyName,
+			objectKeyNameGroupGolden},
+		{"ObjectKeyTag", MarshalObjectKeyTag, UnmarshalObjectKeyTag,
+			objectKeyTagGroupGolden},
+	}
+	for _, test := range tests {
+		s, err := test.marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal%s: %v", test.name, err)
+		}
+		if !bytes.Equal(s, []byte(test.golden)) {
+			t.Errorf("Found %s, want %s", string(s), test.golden)
+		}
+
+		got := &test_pb.TestAllTypes{}
+		err = test.unmarshal(s, got)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal%s: %v", test.name, err)
+		}
+		if got.GetOptionalgroup().GetA() != 111 {
+			t.Errorf("Found %d, want 111 (Optionalgroup.A)",
+				got.GetOptionalgroup().GetA())
+		}
+		if len(got.Repeatedgroup) != 2 {
+			t.Fatalf("Found len %d, want 2 (Repeatedgroup)", len(got.Repeatedgroup))
+		}
+		if !reflect.DeepEqual(got.Repeatedgroup[0].A, []int32{1, 2}) {
+			t.Errorf("Found %v, want [1 2] (Repeatedgroup[0].A)",
+				got.Repeatedgroup[0].A)
+		}
+		if !proto.Equal(got, pb) {
+			t.Errorf("Found %v, want %v", got, pb)
+		}
+	}
+}
+
+const (
+	mixedCaseDescriptor = `
+		name: "mixed_case.proto"
+		message_type: {
+			name: "MixedCase"
+			field: {name: "fooBar" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32}
+			field: {name: "Id_Number" number: 2 label: LABEL_OPTIONAL type: TYPE_INT64}
+		}`
+
+	// keys are the lowercased field names
+	mixedCaseObjectGolden = "{\"foobar\":1,\"id_number\":2}"
+)
+
+func TestMixedCaseFieldName(t *testing.T) {
+	md := messageDescriptor(t, mixedCaseDescriptor, "MixedCase")
+	pb := dynamicpb.NewMessage(md)
+	fields := md.Fields()
+	pb.Set(fields.ByName("fooBar"), protoreflect.ValueOfInt32(1))
+	pb.Set(fields.ByName("Id_Number"), protoreflect.ValueOfInt64(2))
+
+	m := &PBObjectMarshaler{NumberSuffix: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(mixedCaseObjectGolden)) {
+		t.Errorf("Found %s, want %s", string(s), mixedCaseObjectGolden)
+	}
+
+	got := dynamicpb.NewMessage(md)
+	err = UnmarshalObjectKeyName(s, got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+}
+
+var (
+	pbLiteDefaultsGolden = "[null,null,\"1\"," +
+		strings.Repeat("null,", 8) + "1.5," +
+		strings.Repeat("null,", 3) + "\"moo\"," +
+		strings.Repeat("null,", 15) +
+		strings.Repeat("[],", 16) +
+		"null,[],[]," +
+		maxSafeJSStr + ",\"" + oobJSStr + "\"" +
+		"]"
+	objectKeyNameDefaultsGolden = "{" +
+		"\"optional_bytes\":\"moo\"," +
+		"\"optional_float\":1.5," +
+		"\"optional_int64\":\"1\"," +
+		"\"optional_int64_number\":" + maxSafeJSStr + "," +
+		"\"optional_int64_string\":\"" + oobJSStr + "\"" +
+		"}"
+	objectKeyNameProto3DefaultsGolden = "{" +
+		"\"optional_bool\":false," +
+		"\"optional_bytes\":\"\"," +
+		"\"optional_double\":0," +
+		"\"optional_fixed32\":0," +
+		"\"optional_fixed64\":\"0\"," +
+		"\"optional_float\":0," +
+		"\"optional_int32\":0," +
+		"\"optional_int64\":\"0\"," +
+		"\"optional_int64_number\":\"0\"," +
+		"\"optional_int64_string\":\"0\"," +
+		"\"optional_nested_enum\":0," +
+		"\"optional_sfixed32\":0," +
+		"\"optional_sfixed64\":\"0\"," +
+		"\"optional_sint32\":0," +
+		"\"optional_sint64\":\"0\"," +
+		"\"optional_string\":\"\"," +
+		"\"optional_uint32\":0," +
+		"\"optional_uint64\":\"0\"" +
+		"}"
+)
+
+func TestEmitDefaults(t *testing.T) {
+	lm := &PBLiteMarshaler{EmitDefaults: true}
+	s, err := lm.Marshal(&test_pb.TestAllTypes{})
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteDefaultsGolden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteDefaultsGolden)
+	}
+
+	om := &PBObjectMarshaler{EmitDefaults: true}
+	s, err = om.Marshal(&test_pb.TestAllTypes{})
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameDefaultsGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameDefaultsGolden)
+	}
+
+	// set fields keep their values
+	s, err = om.Marshal(&test_pb.TestAllTypes{OptionalInt64: proto.Int64(5)})
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	want := strings.Replace(objectKeyNameDefaultsGolden, "\"1\"", "\"5\"", 1)
+	if !bytes.Equal(s, []byte(want)) {
+		t.Errorf("Found %s, want %s", string(s), want)
+	}
+
+	s, err = om.Marshal(&test3_pb.TestProto3Types{})
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameProto3DefaultsGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameProto3DefaultsGolden)
+	}
+}
+
+const (
+	oneofDefaultsDescriptor = `
+		name: "oneof_defaults.proto"
+		message_type: {
+			name: "OneofDefaults"
+			field: {
+				name: "a" number: 1 label: LABEL_OPTIONAL type: TYPE_INT32
+				default_value: "1" oneof_index: 0
+			}
+			field: {
+				name: "b" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING
+				default_value: "x" oneof_index: 0
+			}
+			field: {
+				name: "c" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32
+				default_value: "3"
+			}
+			oneof_decl: {name: "o"}
+		}`
+
+	// unset oneof members are not written, even with a default
+	objectKeyNameOneofDefaultsGolden  = "{\"c\":3}"
+	objectKeyNameOneofDefaultsBGolden = "{\"b\":\"y\",\"c\":3}"
+)
+
+func TestEmitDefaultsOneof(t *testing.T) {
+	md := messageDescriptor(t, oneofDefaultsDescriptor, "OneofDefaults")
+	om := &PBObjectMarshaler{EmitDefaults: true}
+	s, err := om.Marshal(dynamicpb.NewMessage(md))
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameOneofDefaultsGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameOneofDefaultsGolden)
+	}
+
+	pb := dynamicpb.NewMessage(md)
+	pb.Set(md.Fields().ByName("b"), protoreflect.ValueOfString("y"))
+	s, err = om.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectKeyNameOneofDefaultsBGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectKeyNameOneofDefaultsBGolden)
+	}
+
+	// the output is accepted by the unmarshaler
+	lm := &PBLiteMarshaler{EmitDefaults: true}
+	s, err = lm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	got := dynamicpb.NewMessage(md)
+	err = UnmarshalPBLite(s, got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalPBLite: %v", err)
+	}
+	pb.Set(md.Fields().ByName("c"), protoreflect.ValueOfInt32(3))
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+}
+
+func TestFillDefaults(t *testing.T) {
+	want := &test_pb.TestAllTypes{
+		OptionalInt32:       proto.Int32(7),
+		OptionalInt64:       proto.Int64(1),
+		OptionalFloat:       proto.Float32(1.5),
+		OptionalBytes:       []byte("moo"),
+		OptionalInt64Number: proto.Int64(maxSafeJSInt),
+		OptionalInt64String: proto.Int64(oobJSInt),
+	}
+	want.RepeatedNestedMessage = []*test_pb.TestAllTypes_NestedMessage{{}}
+
+	pb := &test_pb.TestAllTypes{}
+	u := &PBObjectUnmarshaler{FillDefaults: true}
+	err := u.Unmarshal([]byte("{\"optional_int32\":7,"+
+		"\"repeated_nested_message\":[{}]}"), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+
+	pb = &test_pb.TestAllTypes{}
+	lu := &PBLiteUnmarshaler{FillDefaults: true}
+	err = lu.Unmarshal([]byte(pbLiteDefaultsGolden), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	want.OptionalInt32 = nil
+	want.RepeatedNestedMessage = nil
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+
+	// absent fields stay unset by default
+	pb = &test_pb.TestAllTypes{}
+	err = UnmarshalObjectKeyName([]byte("{}"), pb)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if pb.OptionalInt64 != nil {
+		t.Errorf("Found %d, want nil (OptionalInt64)", *pb.OptionalInt64)
+	}
+}
+
+const (
+	objectEnumNamesGolden = "{\"optional_nested_enum\":\"BAZ\"," +
+		"\"repeated_nested_enum\":[\"FOO\",\"BAR\"]}"
+	objectProto3EnumNamesGolden = "{\"optional_nested_enum\":7," +
+		"\"repeated_nested_enum\":[\"BAR\",9]}"
+)
+
+func TestObjectEnumNames(t *testing.T) {
+	pb := &test_pb.TestAllTypes{
+		OptionalNestedEnum: test_pb.TestAllTypes_BAZ.Enum(),
+		RepeatedNestedEnum: []test_pb.TestAllTypes_NestedEnum{
+			test_pb.TestAllTypes_FOO,
+			test_pb.TestAllTypes_BAR,
+		},
+	}
+
+	m := &PBObjectMarshaler{EnumsAsNames: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectEnumNamesGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectEnumNamesGolden)
+	}
+
+	got := &test_pb.TestAllTypes{}
+	err = UnmarshalObjectKeyName(s, got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	// names and numbers may be mixed
+	got = &test_pb.TestAllTypes{}
+	err = UnmarshalObjectKeyName([]byte("{\"optional_nested_enum\":3,"+
+		"\"repeated_nested_enum\":[\"FOO\",2]}"), got)
+	if err != nil {
+		t.Fatalf("unable to UnmarshalObjectKeyName: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	err = UnmarshalObjectKeyName([]byte("{\"optional_nested_enum\":\"QUX\"}"),
+		&test_pb.TestAllTypes{})
+	if !errors.Is(err, errUnknownEnumName) {
+		t.Errorf("Found %v, want %v", err, errUnknownEnumName)
+	}
+
+	// values of open enums without a name are written as numbers
+	pb3 := &test3_pb.TestProto3Types{
+		OptionalNestedEnum: 7,
+		RepeatedNestedEnum: []test3_pb.TestProto3Types_NestedEnum{
+			test3_pb.TestProto3Types_BAR, 9,
+		},
+	}
+	s, err = m.Marshal(pb3)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectProto3EnumNamesGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectProto3EnumNamesGolden)
+	}
+}
+
+const (
+	objectBase64Golden = "{\"optional_bytes\":\"/wD+\"," +
+		"\"repeated_bytes\":[\"+/8=\",\"\"]}"
+	objectBase64URLGolden = "{\"optional_bytes\":\"_wD-\"," +
+		"\"repeated_bytes\":[\"-_8=\",\"\"]}"
+)
+
+func TestBytesEncoding(t *testing.T) {
+	// bytes that are not valid UTF-8
+	pb := &test_pb.TestAllTypes{
+		OptionalBytes: []byte{0xff, 0x00, 0xfe},
+		RepeatedBytes: [][]byte{{0xfb, 0xff}, {}},
+	}
+
+	tests := []struct {
+		enc    *base64.Encoding
+		golden string
+	}{
+		{base64.StdEncoding, objectBase64Golden},
+		{base64.URLEncoding, objectBase64URLGolden},
+	}
+	for _, test := range tests {
+		m := &PBObjectMarshaler{BytesEncoding: test.enc}
+		s, err := m.Marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal: %v", err)
+		}
+		if !bytes.Equal(s, []byte(test.golden)) {
+			t.Errorf("Found %s, want %s", string(s), test.golden)
+		}
+		got := &test_pb.TestAllTypes{}
+		u := &PBObjectUnmarshaler{BytesEncoding: test.enc}
+		err = u.Unmarshal(s, got)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal: %v", err)
+		}
+		if !proto.Equal(got, pb) {
+			t.Errorf("Found %v, want %v", got, pb)
+		}
+
+		lm := &PBLiteMarshaler{BytesEncoding: test.enc}
+		s, err = lm.Marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal: %v", err)
+		}
+		got = &test_pb.TestAllTypes{}
+		lu := &PBLiteUnmarshaler{BytesEncoding: test.enc}
+		err = lu.Unmarshal(s, got)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal: %v", err)
+		}
+		if !proto.Equal(got, pb) {
+			t.Errorf("Found %v, want %v", got, pb)
+		}
+	}
+
+	// URL-safe input is not valid standard base64
+	u := &PBObjectUnmarshaler{BytesEncoding: base64.StdEncoding}
+	err := u.Unmarshal([]byte(objectBase64URLGolden), &test_pb.TestAllTypes{})
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("Found %v, want *DecodeError", err)
+	}
+	if de.Path != "optional_bytes" || de.Expected != "bytes" {
+		t.Errorf("Found %s (%s), want optional_bytes (bytes)", de.Path, de.Expected)
+	}
+	var cie base64.CorruptInputError
+	if !errors.As(err, &cie) {
+		t.Errorf("Found %v, want base64.CorruptInputError", err)
+	}
+}
+
+const (
+	objectWellKnownGolden = "{\"map_duration\":{\"a\":\"90s\"}," +
+		"\"optional_bool_value\":true," +
+		"\"optional_bytes_value\":\"moo\"," +
+		"\"optional_double_value\":1.5," +
+		"\"optional_duration\":\"-1.500s\"," +
+		"\"optional_int64_value\":\"1152921504606846976\"," +
+		"\"optional_string_value\":\"\"," +
+		"\"optional_timestamp\":\"2017-07-14T02:40:00.123Z\"," +
+		"\"repeated_int32_value\":[0,7]," +
+		"\"repeated_timestamp\":[\"1970-01-01T00:00:00Z\"," +
+		"\"1969-12-31T23:59:59.999Z\"]}"
+	objectWellKnownMillisGolden = "{\"map_duration\":{\"a\":90000}," +
+		"\"optional_bool_value\":true," +
+		"\"optional_bytes_value\":\"moo\"," +
+		"\"optional_double_value\":1.5," +
+		"\"optional_duration\":-1500," +
+		"\"optional_int64_value\":\"1152921504606846976\"," +
+		"\"optional_string_value\":\"\"," +
+		"\"optional_timestamp\":1500000000123," +
+		"\"repeated_int32_value\":[0,7]," +
+		"\"repeated_timestamp\":[0,-1]}"
+	pbLiteWellKnownGolden = "[null,\"2017-07-14T02:40:00.123Z\",\"-1.500s\"," +
+		"1.5,null,\"1152921504606846976\",null,null,null,true,\"\",\"moo\"," +
+		"[\"1970-01-01T00:00:00Z\",\"1969-12-31T23:59:59.999Z\"],[0,7]," +
+		"[[null,\"a\",\"90s\"]]]"
+)
+
+func wellKnownMessage() *well_known_test_pb.TestWellKnownTypes {
+	return &well_known_test_pb.TestWellKnownTypes{
+		OptionalTimestamp:   &timestamppb.Timestamp{Seconds: 1500000000, Nanos: 123000000},
+		OptionalDuration:    &durationpb.Duration{Seconds: -1, Nanos: -500000000},
+		OptionalDoubleValue: wrapperspb.Double(1.5),
+		OptionalInt64Value:  wrapperspb.Int64(1 << 60),
+		OptionalBoolValue:   wrapperspb.Bool(true),
+		OptionalStringValue: wrapperspb.String(""),
+		OptionalBytesValue:  wrapperspb.Bytes([]byte("moo")),
+		RepeatedTimestamp: []*timestamppb.Timestamp{
+			{Seconds: 0},
+			{Seconds: -1, Nanos: 999000000},
+		},
+		RepeatedInt32Value: []*wrapperspb.Int32Value{
+			wrapperspb.Int32(0),
+			wrapperspb.Int32(7),
+		},
+		MapDuration: map[string]*durationpb.Duration{
+			"a": {Seconds: 90},
+		},
+	}
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	pb := wellKnownMessage()
+
+	tests := []struct {
+		m      marshaler
+		u      unmarshaler
+		golden string
+	}{
+		{
+			&PBObjectMarshaler{WellKnownTypes: true},
+			&PBObjectUnmarshaler{WellKnownTypes: true},
+			objectWellKnownGolden,
+		},
+		{
+			&PBObjectMarshaler{WellKnownTypes: true, TimeAsMillis: true},
+			&PBObjectUnmarshaler{WellKnownTypes: true},
+			objectWellKnownMillisGolden,
+		},
+		{
+			&PBLiteMarshaler{WellKnownTypes: true},
+			&PBLiteUnmarshaler{WellKnownTypes: true},
+			pbLiteWellKnownGolden,
+		},
+	}
+	for _, test := range tests {
+		s, err := test.m.Marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal: %v", err)
+		}
+		if !bytes.Equal(s, []byte(test.golden)) {
+			t.Errorf("Found %s, want %s", string(s), test.golden)
+		}
+		got := &well_known_test_pb.TestWellKnownTypes{}
+		err = test.u.Unmarshal(s, got)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal: %v", err)
+		}
+		if !proto.Equal(got, pb) {
+			t.Errorf("Found %v, want %v", got, pb)
+		}
+	}
+
+	// timestamps with a UTC offset
+	got := &well_known_test_pb.TestWellKnownTypes{}
+	u := &PBObjectUnmarshaler{WellKnownTypes: true}
+	err := u.Unmarshal([]byte("{\"optional_timestamp\":"+
+		"\"2017-07-14T04:40:00.123+02:00\"}"), got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	want := &well_known_test_pb.TestWellKnownTypes{
+		OptionalTimestamp: pb.OptionalTimestamp,
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("Found %v, want %v", got, want)
+	}
+
+	// without the option they are ordinary messages
+	s, err := MarshalObjectKeyName(want)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	golden := "{\"optional_timestamp\":{\"nanos\":123000000,\"seconds\":\"1500000000\"}}"
+	if !bytes.Equal(s, []byte(golden)) {
+		t.Errorf("Found %s, want %s", string(s), golden)
+	}
+}
+
+func TestWellKnownTypesErrors(t *testing.T) {
+	tests := []struct {
+		json  string
+		path  string
+		cause error
+	}{
+		{"{\"optional_duration\":\"1.5\"}", "optional_duration", errInvalidDuration},
+		{"{\"optional_duration\":\"1.1234567891s\"}", "optional_duration", errInvalidDuration},
+		{"{\"optional_duration\":\"315576000001s\"}", "optional_duration", strconv.ErrRange},
+		{"{\"optional_timestamp\":\"10000-01-01T00:00:00Z\"}", "optional_timestamp", nil},
+		{"{\"optional_timestamp\":{\"seconds\":1}}", "optional_timestamp", nil},
+		{"{\"repeated_timestamp\":[0,1.5]}", "repeated_timestamp[1]", errNotInteger},
+		{"{\"optional_int32_value\":\"7\"}", "optional_int32_value", nil},
+	}
+	u := &PBObjectUnmarshaler{WellKnownTypes: true}
+	for _, test := range tests {
+		err := u.Unmarshal([]byte(test.json), &well_known_test_pb.TestWellKnownTypes{})
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Errorf("Found %v, want *DecodeError (%s)", err, test.json)
+			continue
+		}
+		if de.Path != test.path {
+			t.Errorf("Found %s, want %s", de.Path, test.path)
+		}
+		if test.cause != nil && !errors.Is(err, test.cause) {
+			t.Errorf("Found %v, want %v", err, test.cause)
+		}
+	}
+
+	// out of range values cannot be marshaled
+	pb := &well_known_test_pb.TestWellKnownTypes{
+		OptionalDuration: &durationpb.Duration{Seconds: 1, Nanos: -1},
+	}
+	m := &PBObjectMarshaler{WellKnownTypes: true}
+	_, err := m.Marshal(pb)
+	if err == nil {
+		t.Errorf("Found nil, want error for %v", pb)
+	}
+}
+
+const (
+	objectAnyGolden = "{\"optional_any\":" +
+		"{\"@type\":\"type.googleapis.com/protoclosure.test_pb.TestAllTypes.NestedMessage\"," +
+		"\"b\":5,\"c\":6}," +
+		"\"repeated_any\":[" +
+		"{\"@type\":\"type.googleapis.com/google.protobuf.Timestamp\"," +
+		"\"value\":\"1970-01-01T00:00:01Z\"}," +
+		"{\"@type\":\"type.googleapis.com/google.protobuf.Any\"," +
+		"\"value\":{\"@type\":\"type.googleapis.com/google.protobuf.Int32Value\"," +
+		"\"value\":3}}]}"
+)
+
+var pbLiteAnyGolden = "[null," + strings.Repeat("null,", 11) + "[],[],[]," +
+	"[null,\"type.googleapis.com/protoclosure.test_pb.TestAllTypes.NestedMessage\",[null,5,6]]," +
+	"[[null,\"type.googleapis.com/google.protobuf.Timestamp\"," +
+	"\"1970-01-01T00:00:01Z\"]," +
+	"[null,\"type.googleapis.com/google.protobuf.Any\"," +
+	"[null,\"type.googleapis.com/google.protobuf.Int32Value\",3]]]]"
+
+func newAny(t *testing.T, pb proto.Message) *anypb.Any {
+	a, err := anypb.New(proto.MessageV2(pb))
+	if err != nil {
+		t.Fatalf("unable to create Any: %v", err)
+	}
+	return a
+}
+
+func TestAny(t *testing.T) {
+	pb := &well_known_test_pb.TestWellKnownTypes{
+		OptionalAny: newAny(t, &test_pb.TestAllTypes_NestedMessage{
+			B: proto.Int32(5),
+			C: proto.Int32(6),
+		}),
+		RepeatedAny: []*anypb.Any{
+			newAny(t, &timestamppb.Timestamp{Seconds: 1}),
+			newAny(t, newAny(t, wrapperspb.Int32(3))),
+		},
+	}
+
+	tests := []struct {
+		m      marshaler
+		u      unmarshaler
+		golden string
+	}{
+		{
+			&PBObjectMarshaler{WellKnownTypes: true, AnyResolver: protoregistry.GlobalTypes},
+			&PBObjectUnmarshaler{WellKnownTypes: true, AnyResolver: protoregistry.GlobalTypes},
+			objectAnyGolden,
+		},
+		{
+			&PBLiteMarshaler{WellKnownTypes: true, AnyResolver: protoregistry.GlobalTypes},
+			&PBLiteUnmarshaler{WellKnownTypes: true, AnyResolver: protoregistry.GlobalTypes},
+			pbLiteAnyGolden,
+		},
+	}
+	for _, test := range tests {
+		s, err := test.m.Marshal(pb)
+		if err != nil {
+			t.Fatalf("unable to Marshal: %v", err)
+		}
+		if !bytes.Equal(s, []byte(test.golden)) {
+			t.Errorf("Found %s, want %s", string(s), test.golden)
+		}
+		got := &well_known_test_pb.TestWellKnownTypes{}
+		err = test.u.Unmarshal(s, got)
+		if err != nil {
+			t.Fatalf("unable to Unmarshal: %v", err)
+		}
+		if !proto.Equal(got, pb) {
+			t.Errorf("Found %v, want %v", got, pb)
+		}
+	}
+
+	// empty Any values
+	m := &PBObjectMarshaler{AnyResolver: protoregistry.GlobalTypes}
+	empty := &well_known_test_pb.TestWellKnownTypes{OptionalAny: &anypb.Any{}}
+	s, err := m.Marshal(empty)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	golden := "{\"optional_any\":{}}"
+	if !bytes.Equal(s, []byte(golden)) {
+		t.Errorf("Found %s, want %s", string(s), golden)
+	}
+	got := &well_known_test_pb.TestWellKnownTypes{}
+	u := &PBObjectUnmarshaler{AnyResolver: protoregistry.GlobalTypes}
+	err = u.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(got, empty) {
+		t.Errorf("Found %v, want %v", got, empty)
+	}
+
+	// types unknown to the resolver
+	m = &PBObjectMarshaler{AnyResolver: &protoregistry.Types{}}
+	_, err = m.Marshal(pb)
+	if err == nil {
+		t.Errorf("Found nil, want error for unresolvable Any")
+	}
+	u = &PBObjectUnmarshaler{AnyResolver: &protoregistry.Types{}}
+	err = u.Unmarshal([]byte(objectAnyGolden), &well_known_test_pb.TestWellKnownTypes{})
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("Found %v, want *DecodeError", err)
+	}
+	if de.Path != "optional_any" || !errors.Is(err, protoregistry.NotFound) {
+		t.Errorf("Found %v, want %v at optional_any", err, protoregistry.NotFound)
+	}
+
+	err = u.Unmarshal([]byte("{\"optional_any\":{\"b\":5}}"), &well_known_test_pb.TestWellKnownTypes{})
+	if !errors.Is(err, errMissingTypeURL) {
+		t.Errorf("Found %v, want %v", err, errMissingTypeURL)
+	}
+}
+
+const (
+	structGolden = "{\"a\":1.5,\"b\":\"x\",\"c\":null,\"d\":true," +
+		"\"e\":[1,\"y\",null],\"f\":{\"g\":false}}"
+	objectStructGolden = "{\"map_value\":{\"k\":null,\"l\":true}," +
+		"\"optional_list_value\":[1,[2]]," +
+		"\"optional_struct\":" + structGolden + "," +
+		"\"optional_value\":null," +
+		"\"repeated_value\":[2,null,\"z\"]}"
+)
+
+var pbLiteStructGolden = "[null," + strings.Repeat("null,", 11) +
+	"[],[],[],null,[]," + structGolden + ",null,[1,[2]],[2,null,\"z\"]," +
+	"[[null,\"k\",null],[null,\"l\",true]]]"
+
+func TestStruct(t *testing.T) {
+	st, err := structpb.NewStruct(map[string]interface{}{
+		"a": 1.5,
+		"b": "x",
+		"c": nil,
+		"d": true,
+		"e": []interface{}{1, "y", nil},
+		"f": map[string]interface{}{"g": false},
+	})
+	if err != nil {
+		t.Fatalf("unable to create Struct: %v", err)
+	}
+	lv, err := structpb.NewList([]interface{}{1, []interface{}{2}})
+	if err != nil {
+		t.Fatalf("unable to create ListValue: %v", err)
+	}
+	pb := &well_known_test_pb.TestWellKnownTypes{
+		OptionalStruct:    st,
+		OptionalValue:     structpb.NewNullValue(),
+		OptionalListValue: lv,
+		RepeatedValue: []*structpb.Value{
+			structpb.NewNumberValue(2),
+			structpb.NewNullValue(),
+			structpb.NewStringValue("z"),
+		},
+		MapValue: map[string]*structpb.Value{
+			"k": structpb.NewNullValue(),
+			"l": structpb.NewBoolValue(true),
+		},
+	}
+
+	m := &PBObjectMarshaler{WellKnownTypes: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectStructGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectStructGolden)
+	}
+	got := &well_known_test_pb.TestWellKnownTypes{}
+	u := &PBObjectUnmarshaler{WellKnownTypes: true}
+	err = u.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	lm := &PBLiteMarshaler{WellKnownTypes: true}
+	s, err = lm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteStructGolden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteStructGolden)
+	}
+	got = &well_known_test_pb.TestWellKnownTypes{}
+	lu := &PBLiteUnmarshaler{WellKnownTypes: true}
+	err = lu.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	// a null Value field cannot be told apart from an unset one in PBLite
+	pb.OptionalValue = nil
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	// so unset Value fields are not read back as null
+	pb = &well_known_test_pb.TestWellKnownTypes{OptionalListValue: lv}
+	s, err = lm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	got = &well_known_test_pb.TestWellKnownTypes{}
+	err = lu.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	// Any values holding a Struct are written under "value"
+	am := &PBObjectMarshaler{WellKnownTypes: true, AnyResolver: protoregistry.GlobalTypes}
+	s, err = am.Marshal(&well_known_test_pb.TestWellKnownTypes{OptionalAny: newAny(t, st)})
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	golden := "{\"optional_any\":" +
+		"{\"@type\":\"type.googleapis.com/google.protobuf.Struct\"," +
+		"\"value\":" + structGolden + "}}"
+	if !bytes.Equal(s, []byte(golden)) {
+		t.Errorf("Found %s, want %s", string(s), golden)
+	}
+
+	err = u.Unmarshal([]byte("{\"optional_struct\":{\"a\":{\"b\":[1,[2]]}},"+
+		"\"optional_list_value\":{}}"), &well_known_test_pb.TestWellKnownTypes{})
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("Found %v, want *DecodeError", err)
+	}
+	if de.Path != "optional_list_value" || de.Expected != "google.protobuf.ListValue" {
+		t.Errorf("Found %s (%s), want optional_list_value (google.protobuf.ListValue)",
+			de.Path, de.Expected)
+	}
+}
+
+const (
+	objectNullValueGolden = "{\"optional_null_value\":null," +
+		"\"repeated_null_value\":[null,null]}"
+	objectNullValueNumberGolden = "{\"optional_null_value\":0," +
+		"\"repeated_null_value\":[0,0]}"
+)
+
+var pbLiteNullValueGolden = "[" + strings.Repeat("null,", 12) +
+	"[],[],[],null,[],null,null,null,[],[],null,[null,null]]"
+
+func TestNullValue(t *testing.T) {
+	pb := &well_known_test_pb.TestWellKnownTypes{
+		OptionalNullValue: structpb.NullValue_NULL_VALUE.Enum(),
+		RepeatedNullValue: []structpb.NullValue{
+			structpb.NullValue_NULL_VALUE,
+			structpb.NullValue_NULL_VALUE,
+		},
+	}
+
+	// NullValue is written as null, even with EnumsAsNames
+	m := &PBObjectMarshaler{WellKnownTypes: true, EnumsAsNames: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectNullValueGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectNullValueGolden)
+	}
+	got := &well_known_test_pb.TestWellKnownTypes{}
+	u := &PBObjectUnmarshaler{WellKnownTypes: true}
+	err = u.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+
+	// as a plain enum by default
+	s, err = MarshalObjectKeyName(pb)
+	if err != nil {
+		t.Fatalf("unable to MarshalObjectKeyName: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectNullValueNumberGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectNullValueNumberGolden)
+	}
+
+	lm := &PBLiteMarshaler{WellKnownTypes: true}
+	s, err = lm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(pbLiteNullValueGolden)) {
+		t.Errorf("Found %s, want %s", string(s), pbLiteNullValueGolden)
+	}
+	got = &well_known_test_pb.TestWellKnownTypes{}
+	lu := &PBLiteUnmarshaler{WellKnownTypes: true}
+	err = lu.Unmarshal(s, got)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	// a singular null reads back as unset in PBLite
+	pb.OptionalNullValue = nil
+	if !proto.Equal(got, pb) {
+		t.Errorf("Found %v, want %v", got, pb)
+	}
+}
+
+const objectFieldMaskGolden = "{\"optional_int32\":1," +
+	"\"optional_nested_message\":{\"c\":3}," +
+	"\"repeated_int32\":[4,5]}"
+
+func fieldMaskMessage() *test_pb.TestAllTypes {
+	return &test_pb.TestAllTypes{
+		OptionalInt32:  proto.Int32(1),
+		OptionalString: proto.String("x"),
+		OptionalNestedMessage: &test_pb.TestAllTypes_NestedMessage{
+			B: proto.Int32(2),
+			C: proto.Int32(3),
+		},
+		RepeatedInt32: []int32{4, 5},
+	}
+}
+
+func TestMarshalFieldMask(t *testing.T) {
+	fm := &fieldmaskpb.FieldMask{
+		Paths: []string{"optional_int32", "optional_nested_message.c", "repeated_int32"},
+	}
+	pb := fieldMaskMessage()
+
+	m := &PBObjectMarshaler{FieldMask: fm.GetPaths()}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	if !bytes.Equal(s, []byte(objectFieldMaskGolden)) {
+		t.Errorf("Found %s, want %s", string(s), objectFieldMaskGolden)
+	}
+
+	lm := &PBLiteMarshaler{FieldMask: fm.GetPaths()}
+	s, err = lm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	golden := "[null,1" + strings.Repeat(",null", 16) + ",[null,null,3]" +
+		strings.Repeat(",null", 12) + ",[4,5]]"
+	if !bytes.Equal(s, []byte(golden)) {
+		t.Errorf("Found %s, want %s", string(s), golden)
+	}
+
+	// the message itself is unchanged
+	if !proto.Equal(pb, fieldMaskMessage()) {
+		t.Errorf("Found %v, want %v", pb, fieldMaskMessage())
+	}
+
+	// only the defaults of selected fields are written
+	dm := &PBObjectMarshaler{
+		FieldMask:    append(fm.GetPaths(), "optional_int64"),
+		EmitDefaults: true,
+	}
+	s, err = dm.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	golden = strings.Replace(objectFieldMaskGolden, "\"optional_nested_message\"",
+		"\"optional_int64\":\"1\",\"optional_nested_message\"", 1)
+	if !bytes.Equal(s, []byte(golden)) {
+		t.Errorf("Found %s, want %s", string(s), golden)
+	}
+
+	for _, path := range []string{
+		"optional_int33",
+		"optional_int32.a",
+		"repeated_nested_message.b",
+		"optional_nested_message.d",
+	} {
+		m := &PBObjectMarshaler{FieldMask: []string{path}}
+		_, err := m.Marshal(pb)
+		if err == nil {
+			t.Errorf("Found nil, want error for field mask path %q", path)
+		}
+	}
+}
+
+func TestFieldMaskCheckRequired(t *testing.T) {
+	tests := []struct {
+		mask    []string
+		missing []string
+	}{
+		{[]string{"a", "optional_message.b", "repeated_message"},
+			[]string{"repeated_message[1].a"}},
+		{[]string{"optional_message"}, []string{"optional_message.a"}},
+		{[]string{"a"}, nil},
+	}
+	for _, test := range tests {
+		// only the selected fields are checked on marshal
+		m := &PBObjectMarshaler{FieldMask: test.mask, CheckRequired: true}
+		_, err := m.Marshal(requiredMessage())
+		if test.missing == nil {
+			if err != nil {
+				t.Errorf("Found %v, want nil for field mask %v", err, test.mask)
+			}
+			continue
+		}
+		var rerr *RequiredNotSetError
+		if !errors.As(err, &rerr) {
+			t.Fatalf("Found %v, want *RequiredNotSetError", err)
+		}
+		if !reflect.DeepEqual(rerr.Fields, test.missing) {
+			t.Errorf("Found %v, want %v", rerr.Fields, test.missing)
+		}
+	}
+
+	// the whole updated message is checked on unmarshal
+	u := &PBObjectUnmarshaler{FieldMask: []string{"a"}, CheckRequired: true}
+	err := u.Unmarshal([]byte("{\"a\":1}"), requiredMessage())
+	validateRequiredNotSet(t, err)
+}
+
+func TestUnmarshalFieldMask(t *testing.T) {
+	mask := []string{"optional_int32", "optional_nested_message.c", "repeated_int32"}
+	data := "{\"optional_int32\":7,\"optional_string\":\"y\"," +
+		"\"optional_nested_message\":{\"b\":8}}"
+
+	// masked fields are replaced or cleared, the others are left unchanged
+	want := fieldMaskMessage()
+	want.OptionalInt32 = proto.Int32(7)
+	want.OptionalNestedMessage.C = nil
+	want.RepeatedInt32 = nil
+
+	pb := fieldMaskMessage()
+	u := &PBObjectUnmarshaler{FieldMask: mask}
+	err := u.Unmarshal([]byte(data), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+
+	pb = fieldMaskMessage()
+	lu := &PBLiteUnmarshaler{FieldMask: mask}
+	err = lu.Unmarshal([]byte("[null,7"+strings.Repeat(",null", 12)+
+		",\"y\",null,null,null,[null,8]]"), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	if !proto.Equal(pb, want) {
+		t.Errorf("Found %v, want %v", pb, want)
+	}
+
+	// the message is unchanged when decoding fails
+	pb = fieldMaskMessage()
+	err = u.Unmarshal([]byte("{\"optional_int32\":\"7\"}"), pb)
+	if err == nil {
+		t.Errorf("Found nil, want error")
+	}
+	if !proto.Equal(pb, fieldMaskMessage()) {
+		t.Errorf("Found %v, want %v", pb, fieldMaskMessage())
+	}
+
+	// unknown values are kept whatever the mask
+	pb = fieldMaskMessage()
+	ku := &PBObjectUnmarshaler{FieldMask: mask, KeepUnknown: true}
+	err = ku.Unmarshal([]byte("{\"optional_int32\":7,\"zz\":1}"), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	klu := &PBLiteUnmarshaler{FieldMask: mask, KeepUnknown: true}
+	err = klu.Unmarshal([]byte("[null,8"+strings.Repeat(",null", 98)+",2]"), pb)
+	if err != nil {
+		t.Fatalf("unable to Unmarshal: %v", err)
+	}
+	m := &PBObjectMarshaler{KeyTag: true, EmitUnknown: true}
+	s, err := m.Marshal(pb)
+	if err != nil {
+		t.Fatalf("unable to Marshal: %v", err)
+	}
+	golden := "{\"1\":8,\"100\":2,\"14\":\"x\",\"18\":{\"1\":2},\"zz\":1}"
+	if string(s) != golden {
+		t.Errorf("Found %s, want %s", string(s), golden)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RequiredNotSetError is returned when CheckRequired is set and required
+// fields are missing. As with proto.RequiredNotSetError the error is not
+// fatal: the data is still encoded, or the message still fully decoded.
+type RequiredNotSetError struct {
+	// Full paths of the missing fields, e.g. "repeated_message[2].a".
+	Fields []string
+}
+
+func (e *RequiredNotSetError) Error() string {
+	return fmt.Sprintf("Required fields not set: %s", strings.Join(e.Fields, ", "))
+}
+
+// RequiredNotSet reports whether the error is a missing required field error,
+// matching the method of proto.RequiredNotSetError.
+func (e *RequiredNotSetError) RequiredNotSet() bool {
+	return true
+}
+
+// checkRequired returns a *RequiredNotSetError listing every required field
+// selected by sel that is missing from msg and its nested messages, or nil if
+// all are set.
+func checkRequired(msg protoreflect.Message, sel fieldSelection) error {
+	var missing []string
+	appendMissingRequired(msg, sel, "", &missing)
+	if len(missing) == 0 {
+		return nil
+	}
+	return &RequiredNotSetError{Fields: missing}
+}
+
+func appendMissingRequired(msg protoreflect.Message, sel fieldSelection, prefix string, missing *[]string) {
+	for _, fi := range getMessageInfo(msg.Descriptor()).fields {
+		switch {
+		case !sel.selects(fi.fd):
+			continue
+		case msg.Has(fi.fd):
+			appendNestedMissingRequired(fi.fd, msg.Get(fi.fd), sel.sub(fi.fd),
+				prefix+fieldPathName(fi.fd), missing)
+		case fi.fd.Cardinality() == protoreflect.Required:
+			*missing = append(*missing, prefix+fieldPathName(fi.fd))
+		}
+	}
+
+	// extensions are visited in tag number order
+	var extensions []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.IsExtension() && sel.selects(fd) {
+			extensions = append(extensions, fd)
+		}
+		return true
+	})
+	sort.Slice(extensions, func(i, j int) bool {
+		return extensions[i].Number() < extensions[j].Number()
+	})
+	for _, fd := range extensions {
+		appendNestedMissingRequired(fd, msg.Get(fd), nil, prefix+fieldPathName(fd), missing)
+	}
+}
+
+func appendNestedMissingRequired(fd protoreflect.FieldDescriptor, v protoreflect.Value, sel fieldSelection, path string, missing *[]string) {
+	switch {
+	case fd.IsMap():
+		if fd.MapValue().Message() == nil {
+			return
+		}
+		mp := v.Map()
+		for _, k := range sortedMapKeys(mp) {
+			appendMissingRequired(mp.Get(k).Message(), nil,
+				fmt.Sprintf("%s[%v].", path, k.Interface()), missing)
+		}
+
+	case fd.IsList():
+		if fd.Message() == nil {
+			return
+		}
+		list := v.List()
+		for i := 0; i < list.Len(); i++ {
+			appendMissingRequired(list.Get(i).Message(), nil,
+				fmt.Sprintf("%s[%d].", path, i), missing)
+		}
+
+	case fd.Message() != nil:
+		appendMissingRequired(v.Message(), sel, path+".", missing)
+	}
+}
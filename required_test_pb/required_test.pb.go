@@ -0,0 +1,169 @@
+// A proto2 file used for unit testing required field validation.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: required_test.proto
+
+package required_test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TestRequired struct {
+	state           protoimpl.MessageState   `protogen:"open.v1"`
+	A               *int32                   `protobuf:"varint,1,req,name=a" json:"a,omitempty"`
+	B               *string                  `protobuf:"bytes,2,req,name=b" json:"b,omitempty"`
+	OptionalMessage *TestRequired            `protobuf:"bytes,3,opt,name=optional_message,json=optionalMessage" json:"optional_message,omitempty"`
+	RepeatedMessage []*TestRequired          `protobuf:"bytes,4,rep,name=repeated_message,json=repeatedMessage" json:"repeated_message,omitempty"`
+	MapMessage      map[string]*TestRequired `protobuf:"bytes,5,rep,name=map_message,json=mapMessage" json:"map_message,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TestRequired) Reset() {
+	*x = TestRequired{}
+	mi := &file_required_test_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestRequired) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestRequired) ProtoMessage() {}
+
+func (x *TestRequired) ProtoReflect() protoreflect.Message {
+	mi := &file_required_test_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestRequired.ProtoReflect.Descriptor instead.
+func (*TestRequired) Descriptor() ([]byte, []int) {
+	return file_required_test_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TestRequired) GetA() int32 {
+	if x != nil && x.A != nil {
+		return *x.A
+	}
+	return 0
+}
+
+func (x *TestRequired) GetB() string {
+	if x != nil && x.B != nil {
+		return *x.B
+	}
+	return ""
+}
+
+func (x *TestRequired) GetOptionalMessage() *TestRequired {
+	if x != nil {
+		return x.OptionalMessage
+	}
+	return nil
+}
+
+func (x *TestRequired) GetRepeatedMessage() []*TestRequired {
+	if x != nil {
+		return x.RepeatedMessage
+	}
+	return nil
+}
+
+func (x *TestRequired) GetMapMessage() map[string]*TestRequired {
+	if x != nil {
+		return x.MapMessage
+	}
+	return nil
+}
+
+var File_required_test_proto protoreflect.FileDescriptor
+
+const file_required_test_proto_rawDesc = "" +
+	"\n" +
+	"\x13required_test.proto\x12\frequiredtest\"\xe0\x02\n" +
+	"\fTestRequired\x12\f\n" +
+	"\x01a\x18\x01 \x02(\x05R\x01a\x12\f\n" +
+	"\x01b\x18\x02 \x02(\tR\x01b\x12E\n" +
+	"\x10optional_message\x18\x03 \x01(\v2\x1a.requiredtest.TestRequiredR\x0foptionalMessage\x12E\n" +
+	"\x10repeated_message\x18\x04 \x03(\v2\x1a.requiredtest.TestRequiredR\x0frepeatedMessage\x12K\n" +
+	"\vmap_message\x18\x05 \x03(\v2*.requiredtest.TestRequired.MapMessageEntryR\n" +
+	"mapMessage\x1aY\n" +
+	"\x0fMapMessageEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.requiredtest.TestRequiredR\x05value:\x028\x01B\x1fZ\x1dprotoclosure/required_test_pb"
+
+var (
+	file_required_test_proto_rawDescOnce sync.Once
+	file_required_test_proto_rawDescData []byte
+)
+
+func file_required_test_proto_rawDescGZIP() []byte {
+	file_required_test_proto_rawDescOnce.Do(func() {
+		file_required_test_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_required_test_proto_rawDesc), len(file_required_test_proto_rawDesc)))
+	})
+	return file_required_test_proto_rawDescData
+}
+
+var file_required_test_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_required_test_proto_goTypes = []any{
+	(*TestRequired)(nil), // 0: requiredtest.TestRequired
+	nil,                  // 1: requiredtest.TestRequired.MapMessageEntry
+}
+var file_required_test_proto_depIdxs = []int32{
+	0, // 0: requiredtest.TestRequired.optional_message:type_name -> requiredtest.TestRequired
+	0, // 1: requiredtest.TestRequired.repeated_message:type_name -> requiredtest.TestRequired
+	1, // 2: requiredtest.TestRequired.map_message:type_name -> requiredtest.TestRequired.MapMessageEntry
+	0, // 3: requiredtest.TestRequired.MapMessageEntry.value:type_name -> requiredtest.TestRequired
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_required_test_proto_init() }
+func file_required_test_proto_init() {
+	if File_required_test_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_required_test_proto_rawDesc), len(file_required_test_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_required_test_proto_goTypes,
+		DependencyIndexes: file_required_test_proto_depIdxs,
+		MessageInfos:      file_required_test_proto_msgTypes,
+	}.Build()
+	File_required_test_proto = out.File
+	file_required_test_proto_goTypes = nil
+	file_required_test_proto_depIdxs = nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// PBLiteEncoder writes a sequence of protocol buffers to an output stream in
+// the PBLite JSON format.
+type PBLiteEncoder struct {
+	m   PBLiteMarshaler
+	enc *json.Encoder
+}
+
+// NewPBLiteEncoder returns a new PBLite encoder that writes to w.
+func NewPBLiteEncoder(w io.Writer) *PBLiteEncoder {
+	m := &PBLiteMarshaler{}
+	return m.NewEncoder(w)
+}
+
+// NewEncoder returns a new PBLite encoder that writes to w using the options
+// of m.
+func (m *PBLiteMarshaler) NewEncoder(w io.Writer) *PBLiteEncoder {
+	return &PBLiteEncoder{m: *m, enc: json.NewEncoder(w)}
+}
+
+// Encode writes the PBLite JSON encoding of pb to the stream, followed by a
+// newline character.
+func (e *PBLiteEncoder) Encode(pb proto.Message) error {
+	pbl, err := e.m.encode(pb)
+	if pbl == nil {
+		return err
+	}
+	werr := e.enc.Encode(pbl)
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+// PBLiteDecoder reads a sequence of PBLite JSON encoded protocol buffers from
+// an input stream. The messages must follow one another directly or be
+// separated by whitespace, as written by PBLiteEncoder; they may not be
+// wrapped in an enclosing JSON array.
+type PBLiteDecoder struct {
+	u   PBLiteUnmarshaler
+	dec *json.Decoder
+}
+
+// NewPBLiteDecoder returns a new PBLite decoder that reads from r.
+func NewPBLiteDecoder(r io.Reader) *PBLiteDecoder {
+	u := &PBLiteUnmarshaler{}
+	return u.NewDecoder(r)
+}
+
+// NewDecoder returns a new PBLite decoder that reads from r using the options
+// of u.
+func (u *PBLiteUnmarshaler) NewDecoder(r io.Reader) *PBLiteDecoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &PBLiteDecoder{u: *u, dec: dec}
+}
+
+// Decode reads the next PBLite JSON encoded value from the stream and places
+// the decoded result in pb. It returns io.EOF at the end of the stream.
+func (d *PBLiteDecoder) Decode(pb proto.Message) error {
+	pbl := pbLite{}
+	err := d.dec.Decode(&pbl)
+	if err != nil {
+		return err
+	}
+	return d.u.decode(pbl, pb)
+}
+
+// PBObjectEncoder writes a sequence of protocol buffers to an output stream
+// in the Object JSON format.
+type PBObjectEncoder struct {
+	m   PBObjectMarshaler
+	enc *json.Encoder
+}
+
+// NewPBObjectEncoder returns a new PBObject encoder that writes to w.
+func NewPBObjectEncoder(w io.Writer) *PBObjectEncoder {
+	m := &PBObjectMarshaler{}
+	return m.NewEncoder(w)
+}
+
+// NewEncoder returns a new PBObject encoder that writes to w using the
+// options of m.
+func (m *PBObjectMarshaler) NewEncoder(w io.Writer) *PBObjectEncoder {
+	return &PBObjectEncoder{m: *m, enc: json.NewEncoder(w)}
+}
+
+// Encode writes the Object JSON encoding of pb to the stream, followed by a
+// newline character.
+func (e *PBObjectEncoder) Encode(pb proto.Message) error {
+	pbo, err := e.m.encode(pb)
+	if pbo == nil {
+		return err
+	}
+	werr := e.enc.Encode(pbo)
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+// PBObjectDecoder reads a sequence of Object JSON encoded protocol buffers
+// from an input stream. The messages must follow one another directly or be
+// separated by whitespace, as written by PBObjectEncoder; they may not be
+// wrapped in an enclosing JSON array.
+type PBObjectDecoder struct {
+	u   PBObjectUnmarshaler
+	dec *json.Decoder
+}
+
+// NewPBObjectDecoder returns a new PBObject decoder that reads from r.
+func NewPBObjectDecoder(r io.Reader) *PBObjectDecoder {
+	u := &PBObjectUnmarshaler{}
+	return u.NewDecoder(r)
+}
+
+// NewDecoder returns a new PBObject decoder that reads from r using the
+// options of u.
+func (u *PBObjectUnmarshaler) NewDecoder(r io.Reader) *PBObjectDecoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &PBObjectDecoder{u: *u, dec: dec}
+}
+
+// Decode reads the next Object JSON encoded value from the stream and places
+// the decoded result in pb. It returns io.EOF at the end of the stream.
+func (d *PBObjectDecoder) Decode(pb proto.Message) error {
+	pbo := pbObject{}
+	err := d.dec.Decode(&pbo)
+	if err != nil {
+		return err
+	}
+	return d.u.decode(pbo, pb)
+}
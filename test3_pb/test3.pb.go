@@ -0,0 +1,768 @@
+// A proto3 file used for unit testing implicit and explicit field presence.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: test3.proto
+
+package test3_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TestProto3Types_NestedEnum int32
+
+const (
+	TestProto3Types_FOO TestProto3Types_NestedEnum = 0
+	TestProto3Types_BAR TestProto3Types_NestedEnum = 2
+	TestProto3Types_BAZ TestProto3Types_NestedEnum = 3
+)
+
+// Enum value maps for TestProto3Types_NestedEnum.
+var (
+	TestProto3Types_NestedEnum_name = map[int32]string{
+		0: "FOO",
+		2: "BAR",
+		3: "BAZ",
+	}
+	TestProto3Types_NestedEnum_value = map[string]int32{
+		"FOO": 0,
+		"BAR": 2,
+		"BAZ": 3,
+	}
+)
+
+func (x TestProto3Types_NestedEnum) Enum() *TestProto3Types_NestedEnum {
+	p := new(TestProto3Types_NestedEnum)
+	*p = x
+	return p
+}
+
+func (x TestProto3Types_NestedEnum) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TestProto3Types_NestedEnum) Descriptor() protoreflect.EnumDescriptor {
+	return file_test3_proto_enumTypes[0].Descriptor()
+}
+
+func (TestProto3Types_NestedEnum) Type() protoreflect.EnumType {
+	return &file_test3_proto_enumTypes[0]
+}
+
+func (x TestProto3Types_NestedEnum) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TestProto3Types_NestedEnum.Descriptor instead.
+func (TestProto3Types_NestedEnum) EnumDescriptor() ([]byte, []int) {
+	return file_test3_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type TestProto3Types struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Singular
+	OptionalInt32         int32                          `protobuf:"varint,1,opt,name=optional_int32,json=optionalInt32,proto3" json:"optional_int32,omitempty"`
+	OptionalInt64         int64                          `protobuf:"varint,2,opt,name=optional_int64,json=optionalInt64,proto3" json:"optional_int64,omitempty"`
+	OptionalUint32        uint32                         `protobuf:"varint,3,opt,name=optional_uint32,json=optionalUint32,proto3" json:"optional_uint32,omitempty"`
+	OptionalUint64        uint64                         `protobuf:"varint,4,opt,name=optional_uint64,json=optionalUint64,proto3" json:"optional_uint64,omitempty"`
+	OptionalSint32        int32                          `protobuf:"zigzag32,5,opt,name=optional_sint32,json=optionalSint32,proto3" json:"optional_sint32,omitempty"`
+	OptionalSint64        int64                          `protobuf:"zigzag64,6,opt,name=optional_sint64,json=optionalSint64,proto3" json:"optional_sint64,omitempty"`
+	OptionalFixed32       uint32                         `protobuf:"fixed32,7,opt,name=optional_fixed32,json=optionalFixed32,proto3" json:"optional_fixed32,omitempty"`
+	OptionalFixed64       uint64                         `protobuf:"fixed64,8,opt,name=optional_fixed64,json=optionalFixed64,proto3" json:"optional_fixed64,omitempty"`
+	OptionalSfixed32      int32                          `protobuf:"fixed32,9,opt,name=optional_sfixed32,json=optionalSfixed32,proto3" json:"optional_sfixed32,omitempty"`
+	OptionalSfixed64      int64                          `protobuf:"fixed64,10,opt,name=optional_sfixed64,json=optionalSfixed64,proto3" json:"optional_sfixed64,omitempty"`
+	OptionalFloat         float32                        `protobuf:"fixed32,11,opt,name=optional_float,json=optionalFloat,proto3" json:"optional_float,omitempty"`
+	OptionalDouble        float64                        `protobuf:"fixed64,12,opt,name=optional_double,json=optionalDouble,proto3" json:"optional_double,omitempty"`
+	OptionalBool          bool                           `protobuf:"varint,13,opt,name=optional_bool,json=optionalBool,proto3" json:"optional_bool,omitempty"`
+	OptionalString        string                         `protobuf:"bytes,14,opt,name=optional_string,json=optionalString,proto3" json:"optional_string,omitempty"`
+	OptionalBytes         []byte                         `protobuf:"bytes,15,opt,name=optional_bytes,json=optionalBytes,proto3" json:"optional_bytes,omitempty"`
+	OptionalNestedMessage *TestProto3Types_NestedMessage `protobuf:"bytes,18,opt,name=optional_nested_message,json=optionalNestedMessage,proto3" json:"optional_nested_message,omitempty"`
+	OptionalNestedEnum    TestProto3Types_NestedEnum     `protobuf:"varint,21,opt,name=optional_nested_enum,json=optionalNestedEnum,proto3,enum=TestProto3Types_NestedEnum" json:"optional_nested_enum,omitempty"`
+	// Explicit presence
+	ExplicitInt32       *int32                      `protobuf:"varint,22,opt,name=explicit_int32,json=explicitInt32,proto3,oneof" json:"explicit_int32,omitempty"`
+	ExplicitString      *string                     `protobuf:"bytes,23,opt,name=explicit_string,json=explicitString,proto3,oneof" json:"explicit_string,omitempty"`
+	ExplicitBool        *bool                       `protobuf:"varint,24,opt,name=explicit_bool,json=explicitBool,proto3,oneof" json:"explicit_bool,omitempty"`
+	ExplicitNestedEnum  *TestProto3Types_NestedEnum `protobuf:"varint,25,opt,name=explicit_nested_enum,json=explicitNestedEnum,proto3,enum=TestProto3Types_NestedEnum,oneof" json:"explicit_nested_enum,omitempty"`
+	OptionalInt64Number int64                       `protobuf:"varint,50,opt,name=optional_int64_number,json=optionalInt64Number,proto3" json:"optional_int64_number,omitempty"`
+	OptionalInt64String int64                       `protobuf:"varint,51,opt,name=optional_int64_string,json=optionalInt64String,proto3" json:"optional_int64_string,omitempty"`
+	// Repeated
+	RepeatedInt32         []int32                          `protobuf:"varint,31,rep,packed,name=repeated_int32,json=repeatedInt32,proto3" json:"repeated_int32,omitempty"`
+	RepeatedInt64         []int64                          `protobuf:"varint,32,rep,packed,name=repeated_int64,json=repeatedInt64,proto3" json:"repeated_int64,omitempty"`
+	RepeatedUint32        []uint32                         `protobuf:"varint,33,rep,packed,name=repeated_uint32,json=repeatedUint32,proto3" json:"repeated_uint32,omitempty"`
+	RepeatedUint64        []uint64                         `protobuf:"varint,34,rep,packed,name=repeated_uint64,json=repeatedUint64,proto3" json:"repeated_uint64,omitempty"`
+	RepeatedSint32        []int32                          `protobuf:"zigzag32,35,rep,packed,name=repeated_sint32,json=repeatedSint32,proto3" json:"repeated_sint32,omitempty"`
+	RepeatedSint64        []int64                          `protobuf:"zigzag64,36,rep,packed,name=repeated_sint64,json=repeatedSint64,proto3" json:"repeated_sint64,omitempty"`
+	RepeatedFixed32       []uint32                         `protobuf:"fixed32,37,rep,packed,name=repeated_fixed32,json=repeatedFixed32,proto3" json:"repeated_fixed32,omitempty"`
+	RepeatedFixed64       []uint64                         `protobuf:"fixed64,38,rep,packed,name=repeated_fixed64,json=repeatedFixed64,proto3" json:"repeated_fixed64,omitempty"`
+	RepeatedSfixed32      []int32                          `protobuf:"fixed32,39,rep,packed,name=repeated_sfixed32,json=repeatedSfixed32,proto3" json:"repeated_sfixed32,omitempty"`
+	RepeatedSfixed64      []int64                          `protobuf:"fixed64,40,rep,packed,name=repeated_sfixed64,json=repeatedSfixed64,proto3" json:"repeated_sfixed64,omitempty"`
+	RepeatedFloat         []float32                        `protobuf:"fixed32,41,rep,packed,name=repeated_float,json=repeatedFloat,proto3" json:"repeated_float,omitempty"`
+	RepeatedDouble        []float64                        `protobuf:"fixed64,42,rep,packed,name=repeated_double,json=repeatedDouble,proto3" json:"repeated_double,omitempty"`
+	RepeatedBool          []bool                           `protobuf:"varint,43,rep,packed,name=repeated_bool,json=repeatedBool,proto3" json:"repeated_bool,omitempty"`
+	RepeatedString        []string                         `protobuf:"bytes,44,rep,name=repeated_string,json=repeatedString,proto3" json:"repeated_string,omitempty"`
+	RepeatedBytes         [][]byte                         `protobuf:"bytes,45,rep,name=repeated_bytes,json=repeatedBytes,proto3" json:"repeated_bytes,omitempty"`
+	RepeatedNestedMessage []*TestProto3Types_NestedMessage `protobuf:"bytes,48,rep,name=repeated_nested_message,json=repeatedNestedMessage,proto3" json:"repeated_nested_message,omitempty"`
+	RepeatedNestedEnum    []TestProto3Types_NestedEnum     `protobuf:"varint,49,rep,packed,name=repeated_nested_enum,json=repeatedNestedEnum,proto3,enum=TestProto3Types_NestedEnum" json:"repeated_nested_enum,omitempty"`
+	RepeatedInt64Number   []int64                          `protobuf:"varint,52,rep,packed,name=repeated_int64_number,json=repeatedInt64Number,proto3" json:"repeated_int64_number,omitempty"`
+	RepeatedInt64String   []int64                          `protobuf:"varint,53,rep,packed,name=repeated_int64_string,json=repeatedInt64String,proto3" json:"repeated_int64_string,omitempty"`
+	// Maps
+	MapInt32Int32          map[int32]int32                           `protobuf:"bytes,54,rep,name=map_int32_int32,json=mapInt32Int32,proto3" json:"map_int32_int32,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	MapInt64Int64          map[int64]int64                           `protobuf:"bytes,55,rep,name=map_int64_int64,json=mapInt64Int64,proto3" json:"map_int64_int64,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	MapStringString        map[string]string                         `protobuf:"bytes,56,rep,name=map_string_string,json=mapStringString,proto3" json:"map_string_string,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	MapStringNestedMessage map[string]*TestProto3Types_NestedMessage `protobuf:"bytes,57,rep,name=map_string_nested_message,json=mapStringNestedMessage,proto3" json:"map_string_nested_message,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Types that are valid to be assigned to OneofField:
+	//
+	//	*TestProto3Types_OneofUint32
+	//	*TestProto3Types_OneofNestedMessage
+	//	*TestProto3Types_OneofString
+	//	*TestProto3Types_OneofBytes
+	OneofField    isTestProto3Types_OneofField `protobuf_oneof:"oneof_field"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestProto3Types) Reset() {
+	*x = TestProto3Types{}
+	mi := &file_test3_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestProto3Types) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProto3Types) ProtoMessage() {}
+
+func (x *TestProto3Types) ProtoReflect() protoreflect.Message {
+	mi := &file_test3_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProto3Types.ProtoReflect.Descriptor instead.
+func (*TestProto3Types) Descriptor() ([]byte, []int) {
+	return file_test3_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TestProto3Types) GetOptionalInt32() int32 {
+	if x != nil {
+		return x.OptionalInt32
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalInt64() int64 {
+	if x != nil {
+		return x.OptionalInt64
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalUint32() uint32 {
+	if x != nil {
+		return x.OptionalUint32
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalUint64() uint64 {
+	if x != nil {
+		return x.OptionalUint64
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalSint32() int32 {
+	if x != nil {
+		return x.OptionalSint32
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalSint64() int64 {
+	if x != nil {
+		return x.OptionalSint64
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalFixed32() uint32 {
+	if x != nil {
+		return x.OptionalFixed32
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalFixed64() uint64 {
+	if x != nil {
+		return x.OptionalFixed64
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalSfixed32() int32 {
+	if x != nil {
+		return x.OptionalSfixed32
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalSfixed64() int64 {
+	if x != nil {
+		return x.OptionalSfixed64
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalFloat() float32 {
+	if x != nil {
+		return x.OptionalFloat
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalDouble() float64 {
+	if x != nil {
+		return x.OptionalDouble
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalBool() bool {
+	if x != nil {
+		return x.OptionalBool
+	}
+	return false
+}
+
+func (x *TestProto3Types) GetOptionalString() string {
+	if x != nil {
+		return x.OptionalString
+	}
+	return ""
+}
+
+func (x *TestProto3Types) GetOptionalBytes() []byte {
+	if x != nil {
+		return x.OptionalBytes
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetOptionalNestedMessage() *TestProto3Types_NestedMessage {
+	if x != nil {
+		return x.OptionalNestedMessage
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetOptionalNestedEnum() TestProto3Types_NestedEnum {
+	if x != nil {
+		return x.OptionalNestedEnum
+	}
+	return TestProto3Types_FOO
+}
+
+func (x *TestProto3Types) GetExplicitInt32() int32 {
+	if x != nil && x.ExplicitInt32 != nil {
+		return *x.ExplicitInt32
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetExplicitString() string {
+	if x != nil && x.ExplicitString != nil {
+		return *x.ExplicitString
+	}
+	return ""
+}
+
+func (x *TestProto3Types) GetExplicitBool() bool {
+	if x != nil && x.ExplicitBool != nil {
+		return *x.ExplicitBool
+	}
+	return false
+}
+
+func (x *TestProto3Types) GetExplicitNestedEnum() TestProto3Types_NestedEnum {
+	if x != nil && x.ExplicitNestedEnum != nil {
+		return *x.ExplicitNestedEnum
+	}
+	return TestProto3Types_FOO
+}
+
+func (x *TestProto3Types) GetOptionalInt64Number() int64 {
+	if x != nil {
+		return x.OptionalInt64Number
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOptionalInt64String() int64 {
+	if x != nil {
+		return x.OptionalInt64String
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetRepeatedInt32() []int32 {
+	if x != nil {
+		return x.RepeatedInt32
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedInt64() []int64 {
+	if x != nil {
+		return x.RepeatedInt64
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedUint32() []uint32 {
+	if x != nil {
+		return x.RepeatedUint32
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedUint64() []uint64 {
+	if x != nil {
+		return x.RepeatedUint64
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedSint32() []int32 {
+	if x != nil {
+		return x.RepeatedSint32
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedSint64() []int64 {
+	if x != nil {
+		return x.RepeatedSint64
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedFixed32() []uint32 {
+	if x != nil {
+		return x.RepeatedFixed32
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedFixed64() []uint64 {
+	if x != nil {
+		return x.RepeatedFixed64
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedSfixed32() []int32 {
+	if x != nil {
+		return x.RepeatedSfixed32
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedSfixed64() []int64 {
+	if x != nil {
+		return x.RepeatedSfixed64
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedFloat() []float32 {
+	if x != nil {
+		return x.RepeatedFloat
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedDouble() []float64 {
+	if x != nil {
+		return x.RepeatedDouble
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedBool() []bool {
+	if x != nil {
+		return x.RepeatedBool
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedString() []string {
+	if x != nil {
+		return x.RepeatedString
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedBytes() [][]byte {
+	if x != nil {
+		return x.RepeatedBytes
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedNestedMessage() []*TestProto3Types_NestedMessage {
+	if x != nil {
+		return x.RepeatedNestedMessage
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedNestedEnum() []TestProto3Types_NestedEnum {
+	if x != nil {
+		return x.RepeatedNestedEnum
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedInt64Number() []int64 {
+	if x != nil {
+		return x.RepeatedInt64Number
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetRepeatedInt64String() []int64 {
+	if x != nil {
+		return x.RepeatedInt64String
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetMapInt32Int32() map[int32]int32 {
+	if x != nil {
+		return x.MapInt32Int32
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetMapInt64Int64() map[int64]int64 {
+	if x != nil {
+		return x.MapInt64Int64
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetMapStringString() map[string]string {
+	if x != nil {
+		return x.MapStringString
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetMapStringNestedMessage() map[string]*TestProto3Types_NestedMessage {
+	if x != nil {
+		return x.MapStringNestedMessage
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetOneofField() isTestProto3Types_OneofField {
+	if x != nil {
+		return x.OneofField
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetOneofUint32() uint32 {
+	if x != nil {
+		if x, ok := x.OneofField.(*TestProto3Types_OneofUint32); ok {
+			return x.OneofUint32
+		}
+	}
+	return 0
+}
+
+func (x *TestProto3Types) GetOneofNestedMessage() *TestProto3Types_NestedMessage {
+	if x != nil {
+		if x, ok := x.OneofField.(*TestProto3Types_OneofNestedMessage); ok {
+			return x.OneofNestedMessage
+		}
+	}
+	return nil
+}
+
+func (x *TestProto3Types) GetOneofString() string {
+	if x != nil {
+		if x, ok := x.OneofField.(*TestProto3Types_OneofString); ok {
+			return x.OneofString
+		}
+	}
+	return ""
+}
+
+func (x *TestProto3Types) GetOneofBytes() []byte {
+	if x != nil {
+		if x, ok := x.OneofField.(*TestProto3Types_OneofBytes); ok {
+			return x.OneofBytes
+		}
+	}
+	return nil
+}
+
+type isTestProto3Types_OneofField interface {
+	isTestProto3Types_OneofField()
+}
+
+type TestProto3Types_OneofUint32 struct {
+	OneofUint32 uint32 `protobuf:"varint,111,opt,name=oneof_uint32,json=oneofUint32,proto3,oneof"`
+}
+
+type TestProto3Types_OneofNestedMessage struct {
+	OneofNestedMessage *TestProto3Types_NestedMessage `protobuf:"bytes,112,opt,name=oneof_nested_message,json=oneofNestedMessage,proto3,oneof"`
+}
+
+type TestProto3Types_OneofString struct {
+	OneofString string `protobuf:"bytes,113,opt,name=oneof_string,json=oneofString,proto3,oneof"`
+}
+
+type TestProto3Types_OneofBytes struct {
+	OneofBytes []byte `protobuf:"bytes,114,opt,name=oneof_bytes,json=oneofBytes,proto3,oneof"`
+}
+
+func (*TestProto3Types_OneofUint32) isTestProto3Types_OneofField() {}
+
+func (*TestProto3Types_OneofNestedMessage) isTestProto3Types_OneofField() {}
+
+func (*TestProto3Types_OneofString) isTestProto3Types_OneofField() {}
+
+func (*TestProto3Types_OneofBytes) isTestProto3Types_OneofField() {}
+
+type TestProto3Types_NestedMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	B             int32                  `protobuf:"varint,1,opt,name=b,proto3" json:"b,omitempty"`
+	C             int32                  `protobuf:"varint,2,opt,name=c,proto3" json:"c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestProto3Types_NestedMessage) Reset() {
+	*x = TestProto3Types_NestedMessage{}
+	mi := &file_test3_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestProto3Types_NestedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProto3Types_NestedMessage) ProtoMessage() {}
+
+func (x *TestProto3Types_NestedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_test3_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProto3Types_NestedMessage.ProtoReflect.Descriptor instead.
+func (*TestProto3Types_NestedMessage) Descriptor() ([]byte, []int) {
+	return file_test3_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *TestProto3Types_NestedMessage) GetB() int32 {
+	if x != nil {
+		return x.B
+	}
+	return 0
+}
+
+func (x *TestProto3Types_NestedMessage) GetC() int32 {
+	if x != nil {
+		return x.C
+	}
+	return 0
+}
+
+var File_test3_proto protoreflect.FileDescriptor
+
+const file_test3_proto_rawDesc = "" +
+	"\n" +
+	"\vtest3.proto\"\xd6\x17\n" +
+	"\x0fTestProto3Types\x12%\n" +
+	"\x0eoptional_int32\x18\x01 \x01(\x05R\roptionalInt32\x12%\n" +
+	"\x0eoptional_int64\x18\x02 \x01(\x03R\roptionalInt64\x12'\n" +
+	"\x0foptional_uint32\x18\x03 \x01(\rR\x0eoptionalUint32\x12'\n" +
+	"\x0foptional_uint64\x18\x04 \x01(\x04R\x0eoptionalUint64\x12'\n" +
+	"\x0foptional_sint32\x18\x05 \x01(\x11R\x0eoptionalSint32\x12'\n" +
+	"\x0foptional_sint64\x18\x06 \x01(\x12R\x0eoptionalSint64\x12)\n" +
+	"\x10optional_fixed32\x18\a \x01(\aR\x0foptionalFixed32\x12)\n" +
+	"\x10optional_fixed64\x18\b \x01(\x06R\x0foptionalFixed64\x12+\n" +
+	"\x11optional_sfixed32\x18\t \x01(\x0fR\x10optionalSfixed32\x12+\n" +
+	"\x11optional_sfixed64\x18\n" +
+	" \x01(\x10R\x10optionalSfixed64\x12%\n" +
+	"\x0eoptional_float\x18\v \x01(\x02R\roptionalFloat\x12'\n" +
+	"\x0foptional_double\x18\f \x01(\x01R\x0eoptionalDouble\x12#\n" +
+	"\roptional_bool\x18\r \x01(\bR\foptionalBool\x12'\n" +
+	"\x0foptional_string\x18\x0e \x01(\tR\x0eoptionalString\x12%\n" +
+	"\x0eoptional_bytes\x18\x0f \x01(\fR\roptionalBytes\x12V\n" +
+	"\x17optional_nested_message\x18\x12 \x01(\v2\x1e.TestProto3Types.NestedMessageR\x15optionalNestedMessage\x12M\n" +
+	"\x14optional_nested_enum\x18\x15 \x01(\x0e2\x1b.TestProto3Types.NestedEnumR\x12optionalNestedEnum\x12*\n" +
+	"\x0eexplicit_int32\x18\x16 \x01(\x05H\x01R\rexplicitInt32\x88\x01\x01\x12,\n" +
+	"\x0fexplicit_string\x18\x17 \x01(\tH\x02R\x0eexplicitString\x88\x01\x01\x12(\n" +
+	"\rexplicit_bool\x18\x18 \x01(\bH\x03R\fexplicitBool\x88\x01\x01\x12R\n" +
+	"\x14explicit_nested_enum\x18\x19 \x01(\x0e2\x1b.TestProto3Types.NestedEnumH\x04R\x12explicitNestedEnum\x88\x01\x01\x122\n" +
+	"\x15optional_int64_number\x182 \x01(\x03R\x13optionalInt64Number\x122\n" +
+	"\x15optional_int64_string\x183 \x01(\x03R\x13optionalInt64String\x12%\n" +
+	"\x0erepeated_int32\x18\x1f \x03(\x05R\rrepeatedInt32\x12%\n" +
+	"\x0erepeated_int64\x18  \x03(\x03R\rrepeatedInt64\x12'\n" +
+	"\x0frepeated_uint32\x18! \x03(\rR\x0erepeatedUint32\x12'\n" +
+	"\x0frepeated_uint64\x18\" \x03(\x04R\x0erepeatedUint64\x12'\n" +
+	"\x0frepeated_sint32\x18# \x03(\x11R\x0erepeatedSint32\x12'\n" +
+	"\x0frepeated_sint64\x18$ \x03(\x12R\x0erepeatedSint64\x12)\n" +
+	"\x10repeated_fixed32\x18% \x03(\aR\x0frepeatedFixed32\x12)\n" +
+	"\x10repeated_fixed64\x18& \x03(\x06R\x0frepeatedFixed64\x12+\n" +
+	"\x11repeated_sfixed32\x18' \x03(\x0fR\x10repeatedSfixed32\x12+\n" +
+	"\x11repeated_sfixed64\x18( \x03(\x10R\x10repeatedSfixed64\x12%\n" +
+	"\x0erepeated_float\x18) \x03(\x02R\rrepeatedFloat\x12'\n" +
+	"\x0frepeated_double\x18* \x03(\x01R\x0erepeatedDouble\x12#\n" +
+	"\rrepeated_bool\x18+ \x03(\bR\frepeatedBool\x12'\n" +
+	"\x0frepeated_string\x18, \x03(\tR\x0erepeatedString\x12%\n" +
+	"\x0erepeated_bytes\x18- \x03(\fR\rrepeatedBytes\x12V\n" +
+	"\x17repeated_nested_message\x180 \x03(\v2\x1e.TestProto3Types.NestedMessageR\x15repeatedNestedMessage\x12M\n" +
+	"\x14repeated_nested_enum\x181 \x03(\x0e2\x1b.TestProto3Types.NestedEnumR\x12repeatedNestedEnum\x122\n" +
+	"\x15repeated_int64_number\x184 \x03(\x03R\x13repeatedInt64Number\x122\n" +
+	"\x15repeated_int64_string\x185 \x03(\x03R\x13repeatedInt64String\x12K\n" +
+	"\x0fmap_int32_int32\x186 \x03(\v2#.TestProto3Types.MapInt32Int32EntryR\rmapInt32Int32\x12K\n" +
+	"\x0fmap_int64_int64\x187 \x03(\v2#.TestProto3Types.MapInt64Int64EntryR\rmapInt64Int64\x12Q\n" +
+	"\x11map_string_string\x188 \x03(\v2%.TestProto3Types.MapStringStringEntryR\x0fmapStringString\x12g\n" +
+	"\x19map_string_nested_message\x189 \x03(\v2,.TestProto3Types.MapStringNestedMessageEntryR\x16mapStringNestedMessage\x12#\n" +
+	"\foneof_uint32\x18o \x01(\rH\x00R\voneofUint32\x12R\n" +
+	"\x14oneof_nested_message\x18p \x01(\v2\x1e.TestProto3Types.NestedMessageH\x00R\x12oneofNestedMessage\x12#\n" +
+	"\foneof_string\x18q \x01(\tH\x00R\voneofString\x12!\n" +
+	"\voneof_bytes\x18r \x01(\fH\x00R\n" +
+	"oneofBytes\x1a+\n" +
+	"\rNestedMessage\x12\f\n" +
+	"\x01b\x18\x01 \x01(\x05R\x01b\x12\f\n" +
+	"\x01c\x18\x02 \x01(\x05R\x01c\x1a@\n" +
+	"\x12MapInt32Int32Entry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x05R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a@\n" +
+	"\x12MapInt64Int64Entry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1aB\n" +
+	"\x14MapStringStringEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1ai\n" +
+	"\x1bMapStringNestedMessageEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x124\n" +
+	"\x05value\x18\x02 \x01(\v2\x1e.TestProto3Types.NestedMessageR\x05value:\x028\x01\"'\n" +
+	"\n" +
+	"NestedEnum\x12\a\n" +
+	"\x03FOO\x10\x00\x12\a\n" +
+	"\x03BAR\x10\x02\x12\a\n" +
+	"\x03BAZ\x10\x03B\r\n" +
+	"\voneof_fieldB\x11\n" +
+	"\x0f_explicit_int32B\x12\n" +
+	"\x10_explicit_stringB\x10\n" +
+	"\x0e_explicit_boolB\x17\n" +
+	"\x15_explicit_nested_enumB\x17Z\x15protoclosure/test3_pbb\x06proto3"
+
+var (
+	file_test3_proto_rawDescOnce sync.Once
+	file_test3_proto_rawDescData []byte
+)
+
+func file_test3_proto_rawDescGZIP() []byte {
+	file_test3_proto_rawDescOnce.Do(func() {
+		file_test3_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_test3_proto_rawDesc), len(file_test3_proto_rawDesc)))
+	})
+	return file_test3_proto_rawDescData
+}
+
+var file_test3_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_test3_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_test3_proto_goTypes = []any{
+	(TestProto3Types_NestedEnum)(0),       // 0: TestProto3Types.NestedEnum
+	(*TestProto3Types)(nil),               // 1: TestProto3Types
+	(*TestProto3Types_NestedMessage)(nil), // 2: TestProto3Types.NestedMessage
+	nil,                                   // 3: TestProto3Types.MapInt32Int32Entry
+	nil,                                   // 4: TestProto3Types.MapInt64Int64Entry
+	nil,                                   // 5: TestProto3Types.MapStringStringEntry
+	nil,                                   // 6: TestProto3Types.MapStringNestedMessageEntry
+}
+var file_test3_proto_depIdxs = []int32{
+	2,  // 0: TestProto3Types.optional_nested_message:type_name -> TestProto3Types.NestedMessage
+	0,  // 1: TestProto3Types.optional_nested_enum:type_name -> TestProto3Types.NestedEnum
+	0,  // 2: TestProto3Types.explicit_nested_enum:type_name -> TestProto3Types.NestedEnum
+	2,  // 3: TestProto3Types.repeated_nested_message:type_name -> TestProto3Types.NestedMessage
+	0,  // 4: TestProto3Types.repeated_nested_enum:type_name -> TestProto3Types.NestedEnum
+	3,  // 5: TestProto3Types.map_int32_int32:type_name -> TestProto3Types.MapInt32Int32Entry
+	4,  // 6: TestProto3Types.map_int64_int64:type_name -> TestProto3Types.MapInt64Int64Entry
+	5,  // 7: TestProto3Types.map_string_string:type_name -> TestProto3Types.MapStringStringEntry
+	6,  // 8: TestProto3Types.map_string_nested_message:type_name -> TestProto3Types.MapStringNestedMessageEntry
+	2,  // 9: TestProto3Types.oneof_nested_message:type_name -> TestProto3Types.NestedMessage
+	2,  // 10: TestProto3Types.MapStringNestedMessageEntry.value:type_name -> TestProto3Types.NestedMessage
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_test3_proto_init() }
+func file_test3_proto_init() {
+	if File_test3_proto != nil {
+		return
+	}
+	file_test3_proto_msgTypes[0].OneofWrappers = []any{
+		(*TestProto3Types_OneofUint32)(nil),
+		(*TestProto3Types_OneofNestedMessage)(nil),
+		(*TestProto3Types_OneofString)(nil),
+		(*TestProto3Types_OneofBytes)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_test3_proto_rawDesc), len(file_test3_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_test3_proto_goTypes,
+		DependencyIndexes: file_test3_proto_depIdxs,
+		EnumInfos:         file_test3_proto_enumTypes,
+		MessageInfos:      file_test3_proto_msgTypes,
+	}.Build()
+	File_test3_proto = out.File
+	file_test3_proto_goTypes = nil
+	file_test3_proto_depIdxs = nil
+}
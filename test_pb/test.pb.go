@@ -1,24 +1,33 @@
-// Code generated by protoc-gen-go.
-// source: gopkg.in/samegoal/protoclosure.v0/test.proto
-// DO NOT EDIT!
+// Copyright 2008 Google Inc.  All rights reserved.
+//
+// A proto file used for unit testing the ECMAScript
+// compiler and framework.
+//
+// Modified by Andy Hochhaus <ahochhaus@samegoal.com> to be compatible with
+// use in a protoc compiler plugin.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: test.proto
 
-/*
-Package test is a generated protocol buffer package.
-
-It is generated from these files:
-	gopkg.in/samegoal/protoclosure.v0/test.proto
-
-It has these top-level messages:
-	TestAllTypes
-*/
 package test_pb
 
-import proto "github.com/golang/protobuf/proto"
-import math "math"
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = math.Inf
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 type TestAllTypes_NestedEnum int32
 
@@ -28,428 +37,687 @@ const (
 	TestAllTypes_BAZ TestAllTypes_NestedEnum = 3
 )
 
-var TestAllTypes_NestedEnum_name = map[int32]string{
-	0: "FOO",
-	2: "BAR",
-	3: "BAZ",
-}
-var TestAllTypes_NestedEnum_value = map[string]int32{
-	"FOO": 0,
-	"BAR": 2,
-	"BAZ": 3,
-}
+// Enum value maps for TestAllTypes_NestedEnum.
+var (
+	TestAllTypes_NestedEnum_name = map[int32]string{
+		0: "FOO",
+		2: "BAR",
+		3: "BAZ",
+	}
+	TestAllTypes_NestedEnum_value = map[string]int32{
+		"FOO": 0,
+		"BAR": 2,
+		"BAZ": 3,
+	}
+)
 
 func (x TestAllTypes_NestedEnum) Enum() *TestAllTypes_NestedEnum {
 	p := new(TestAllTypes_NestedEnum)
 	*p = x
 	return p
 }
+
 func (x TestAllTypes_NestedEnum) String() string {
-	return proto.EnumName(TestAllTypes_NestedEnum_name, int32(x))
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TestAllTypes_NestedEnum) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_proto_enumTypes[0].Descriptor()
+}
+
+func (TestAllTypes_NestedEnum) Type() protoreflect.EnumType {
+	return &file_test_proto_enumTypes[0]
+}
+
+func (x TestAllTypes_NestedEnum) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
 }
-func (x *TestAllTypes_NestedEnum) UnmarshalJSON(data []byte) error {
-	value, err := proto.UnmarshalJSONEnum(TestAllTypes_NestedEnum_value, data, "TestAllTypes_NestedEnum")
+
+// Deprecated: Do not use.
+func (x *TestAllTypes_NestedEnum) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
 	if err != nil {
 		return err
 	}
-	*x = TestAllTypes_NestedEnum(value)
+	*x = TestAllTypes_NestedEnum(num)
 	return nil
 }
 
+// Deprecated: Use TestAllTypes_NestedEnum.Descriptor instead.
+func (TestAllTypes_NestedEnum) EnumDescriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0, 0}
+}
+
 type TestAllTypes struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
 	// Singular
-	OptionalInt32         *int32                      `protobuf:"varint,1,opt,name=optional_int32" json:"optional_int32,omitempty"`
-	OptionalInt64         *int64                      `protobuf:"varint,2,opt,name=optional_int64,def=1" json:"optional_int64,omitempty"`
-	OptionalUint32        *uint32                     `protobuf:"varint,3,opt,name=optional_uint32" json:"optional_uint32,omitempty"`
-	OptionalUint64        *uint64                     `protobuf:"varint,4,opt,name=optional_uint64" json:"optional_uint64,omitempty"`
-	OptionalSint32        *int32                      `protobuf:"zigzag32,5,opt,name=optional_sint32" json:"optional_sint32,omitempty"`
-	OptionalSint64        *int64                      `protobuf:"zigzag64,6,opt,name=optional_sint64" json:"optional_sint64,omitempty"`
-	OptionalFixed32       *uint32                     `protobuf:"fixed32,7,opt,name=optional_fixed32" json:"optional_fixed32,omitempty"`
-	OptionalFixed64       *uint64                     `protobuf:"fixed64,8,opt,name=optional_fixed64" json:"optional_fixed64,omitempty"`
-	OptionalSfixed32      *int32                      `protobuf:"fixed32,9,opt,name=optional_sfixed32" json:"optional_sfixed32,omitempty"`
-	OptionalSfixed64      *int64                      `protobuf:"fixed64,10,opt,name=optional_sfixed64" json:"optional_sfixed64,omitempty"`
-	OptionalFloat         *float32                    `protobuf:"fixed32,11,opt,name=optional_float,def=1.5" json:"optional_float,omitempty"`
-	OptionalDouble        *float64                    `protobuf:"fixed64,12,opt,name=optional_double" json:"optional_double,omitempty"`
-	OptionalBool          *bool                       `protobuf:"varint,13,opt,name=optional_bool" json:"optional_bool,omitempty"`
-	OptionalString        *string                     `protobuf:"bytes,14,opt,name=optional_string" json:"optional_string,omitempty"`
-	OptionalBytes         []byte                      `protobuf:"bytes,15,opt,name=optional_bytes,def=moo" json:"optional_bytes,omitempty"`
-	Optionalgroup         *TestAllTypes_OptionalGroup `protobuf:"group,16,opt,name=OptionalGroup" json:"optionalgroup,omitempty"`
-	OptionalNestedMessage *TestAllTypes_NestedMessage `protobuf:"bytes,18,opt,name=optional_nested_message" json:"optional_nested_message,omitempty"`
-	OptionalNestedEnum    *TestAllTypes_NestedEnum    `protobuf:"varint,21,opt,name=optional_nested_enum,enum=TestAllTypes_NestedEnum" json:"optional_nested_enum,omitempty"`
-	OptionalInt64Number   *int64                      `protobuf:"varint,50,opt,name=optional_int64_number,def=9007199254740991" json:"optional_int64_number,omitempty"`
-	OptionalInt64String   *int64                      `protobuf:"varint,51,opt,name=optional_int64_string,def=9007199254740993" json:"optional_int64_string,omitempty"`
+	OptionalInt32         *int32                      `protobuf:"varint,1,opt,name=optional_int32,json=optionalInt32" json:"optional_int32,omitempty"`
+	OptionalInt64         *int64                      `protobuf:"varint,2,opt,name=optional_int64,json=optionalInt64,def=1" json:"optional_int64,omitempty"`
+	OptionalUint32        *uint32                     `protobuf:"varint,3,opt,name=optional_uint32,json=optionalUint32" json:"optional_uint32,omitempty"`
+	OptionalUint64        *uint64                     `protobuf:"varint,4,opt,name=optional_uint64,json=optionalUint64" json:"optional_uint64,omitempty"`
+	OptionalSint32        *int32                      `protobuf:"zigzag32,5,opt,name=optional_sint32,json=optionalSint32" json:"optional_sint32,omitempty"`
+	OptionalSint64        *int64                      `protobuf:"zigzag64,6,opt,name=optional_sint64,json=optionalSint64" json:"optional_sint64,omitempty"`
+	OptionalFixed32       *uint32                     `protobuf:"fixed32,7,opt,name=optional_fixed32,json=optionalFixed32" json:"optional_fixed32,omitempty"`
+	OptionalFixed64       *uint64                     `protobuf:"fixed64,8,opt,name=optional_fixed64,json=optionalFixed64" json:"optional_fixed64,omitempty"`
+	OptionalSfixed32      *int32                      `protobuf:"fixed32,9,opt,name=optional_sfixed32,json=optionalSfixed32" json:"optional_sfixed32,omitempty"`
+	OptionalSfixed64      *int64                      `protobuf:"fixed64,10,opt,name=optional_sfixed64,json=optionalSfixed64" json:"optional_sfixed64,omitempty"`
+	OptionalFloat         *float32                    `protobuf:"fixed32,11,opt,name=optional_float,json=optionalFloat,def=1.5" json:"optional_float,omitempty"`
+	OptionalDouble        *float64                    `protobuf:"fixed64,12,opt,name=optional_double,json=optionalDouble" json:"optional_double,omitempty"`
+	OptionalBool          *bool                       `protobuf:"varint,13,opt,name=optional_bool,json=optionalBool" json:"optional_bool,omitempty"`
+	OptionalString        *string                     `protobuf:"bytes,14,opt,name=optional_string,json=optionalString" json:"optional_string,omitempty"`
+	OptionalBytes         []byte                      `protobuf:"bytes,15,opt,name=optional_bytes,json=optionalBytes,def=moo" json:"optional_bytes,omitempty"`
+	Optionalgroup         *TestAllTypes_OptionalGroup `protobuf:"group,16,opt,name=OptionalGroup,json=optionalgroup" json:"optionalgroup,omitempty"`
+	OptionalNestedMessage *TestAllTypes_NestedMessage `protobuf:"bytes,18,opt,name=optional_nested_message,json=optionalNestedMessage" json:"optional_nested_message,omitempty"`
+	OptionalNestedEnum    *TestAllTypes_NestedEnum    `protobuf:"varint,21,opt,name=optional_nested_enum,json=optionalNestedEnum,enum=protoclosure.test_pb.TestAllTypes_NestedEnum" json:"optional_nested_enum,omitempty"`
+	OptionalInt64Number   *int64                      `protobuf:"varint,50,opt,name=optional_int64_number,json=optionalInt64Number,def=9007199254740991" json:"optional_int64_number,omitempty"`
+	OptionalInt64String   *int64                      `protobuf:"varint,51,opt,name=optional_int64_string,json=optionalInt64String,def=9007199254740993" json:"optional_int64_string,omitempty"`
 	// Repeated
-	RepeatedInt32         []int32                       `protobuf:"varint,31,rep,name=repeated_int32" json:"repeated_int32,omitempty"`
-	RepeatedInt64         []int64                       `protobuf:"varint,32,rep,name=repeated_int64" json:"repeated_int64,omitempty"`
-	RepeatedUint32        []uint32                      `protobuf:"varint,33,rep,name=repeated_uint32" json:"repeated_uint32,omitempty"`
-	RepeatedUint64        []uint64                      `protobuf:"varint,34,rep,name=repeated_uint64" json:"repeated_uint64,omitempty"`
-	RepeatedSint32        []int32                       `protobuf:"zigzag32,35,rep,name=repeated_sint32" json:"repeated_sint32,omitempty"`
-	RepeatedSint64        []int64                       `protobuf:"zigzag64,36,rep,name=repeated_sint64" json:"repeated_sint64,omitempty"`
-	RepeatedFixed32       []uint32                      `protobuf:"fixed32,37,rep,name=repeated_fixed32" json:"repeated_fixed32,omitempty"`
-	RepeatedFixed64       []uint64                      `protobuf:"fixed64,38,rep,name=repeated_fixed64" json:"repeated_fixed64,omitempty"`
-	RepeatedSfixed32      []int32                       `protobuf:"fixed32,39,rep,name=repeated_sfixed32" json:"repeated_sfixed32,omitempty"`
-	RepeatedSfixed64      []int64                       `protobuf:"fixed64,40,rep,name=repeated_sfixed64" json:"repeated_sfixed64,omitempty"`
-	RepeatedFloat         []float32                     `protobuf:"fixed32,41,rep,name=repeated_float" json:"repeated_float,omitempty"`
-	RepeatedDouble        []float64                     `protobuf:"fixed64,42,rep,name=repeated_double" json:"repeated_double,omitempty"`
-	RepeatedBool          []bool                        `protobuf:"varint,43,rep,name=repeated_bool" json:"repeated_bool,omitempty"`
-	RepeatedString        []string                      `protobuf:"bytes,44,rep,name=repeated_string" json:"repeated_string,omitempty"`
-	RepeatedBytes         [][]byte                      `protobuf:"bytes,45,rep,name=repeated_bytes" json:"repeated_bytes,omitempty"`
-	Repeatedgroup         []*TestAllTypes_RepeatedGroup `protobuf:"group,46,rep,name=RepeatedGroup" json:"repeatedgroup,omitempty"`
-	RepeatedNestedMessage []*TestAllTypes_NestedMessage `protobuf:"bytes,48,rep,name=repeated_nested_message" json:"repeated_nested_message,omitempty"`
-	RepeatedNestedEnum    []TestAllTypes_NestedEnum     `protobuf:"varint,49,rep,name=repeated_nested_enum,enum=TestAllTypes_NestedEnum" json:"repeated_nested_enum,omitempty"`
-	RepeatedInt64Number   []int64                       `protobuf:"varint,52,rep,name=repeated_int64_number" json:"repeated_int64_number,omitempty"`
-	RepeatedInt64String   []int64                       `protobuf:"varint,53,rep,name=repeated_int64_string" json:"repeated_int64_string,omitempty"`
-	XXX_unrecognized      []byte                        `json:"-"`
-}
-
-func (m *TestAllTypes) Reset()         { *m = TestAllTypes{} }
-func (m *TestAllTypes) String() string { return proto.CompactTextString(m) }
-func (*TestAllTypes) ProtoMessage()    {}
-
-const Default_TestAllTypes_OptionalInt64 int64 = 1
-const Default_TestAllTypes_OptionalFloat float32 = 1.5
-
-var Default_TestAllTypes_OptionalBytes []byte = []byte("moo")
-
-const Default_TestAllTypes_OptionalInt64Number int64 = 9007199254740991
-const Default_TestAllTypes_OptionalInt64String int64 = 9007199254740993
-
-func (m *TestAllTypes) GetOptionalInt32() int32 {
-	if m != nil && m.OptionalInt32 != nil {
-		return *m.OptionalInt32
+	RepeatedInt32         []int32                       `protobuf:"varint,31,rep,name=repeated_int32,json=repeatedInt32" json:"repeated_int32,omitempty"`
+	RepeatedInt64         []int64                       `protobuf:"varint,32,rep,name=repeated_int64,json=repeatedInt64" json:"repeated_int64,omitempty"`
+	RepeatedUint32        []uint32                      `protobuf:"varint,33,rep,name=repeated_uint32,json=repeatedUint32" json:"repeated_uint32,omitempty"`
+	RepeatedUint64        []uint64                      `protobuf:"varint,34,rep,name=repeated_uint64,json=repeatedUint64" json:"repeated_uint64,omitempty"`
+	RepeatedSint32        []int32                       `protobuf:"zigzag32,35,rep,name=repeated_sint32,json=repeatedSint32" json:"repeated_sint32,omitempty"`
+	RepeatedSint64        []int64                       `protobuf:"zigzag64,36,rep,name=repeated_sint64,json=repeatedSint64" json:"repeated_sint64,omitempty"`
+	RepeatedFixed32       []uint32                      `protobuf:"fixed32,37,rep,name=repeated_fixed32,json=repeatedFixed32" json:"repeated_fixed32,omitempty"`
+	RepeatedFixed64       []uint64                      `protobuf:"fixed64,38,rep,name=repeated_fixed64,json=repeatedFixed64" json:"repeated_fixed64,omitempty"`
+	RepeatedSfixed32      []int32                       `protobuf:"fixed32,39,rep,name=repeated_sfixed32,json=repeatedSfixed32" json:"repeated_sfixed32,omitempty"`
+	RepeatedSfixed64      []int64                       `protobuf:"fixed64,40,rep,name=repeated_sfixed64,json=repeatedSfixed64" json:"repeated_sfixed64,omitempty"`
+	RepeatedFloat         []float32                     `protobuf:"fixed32,41,rep,name=repeated_float,json=repeatedFloat" json:"repeated_float,omitempty"`
+	RepeatedDouble        []float64                     `protobuf:"fixed64,42,rep,name=repeated_double,json=repeatedDouble" json:"repeated_double,omitempty"`
+	RepeatedBool          []bool                        `protobuf:"varint,43,rep,name=repeated_bool,json=repeatedBool" json:"repeated_bool,omitempty"`
+	RepeatedString        []string                      `protobuf:"bytes,44,rep,name=repeated_string,json=repeatedString" json:"repeated_string,omitempty"`
+	RepeatedBytes         [][]byte                      `protobuf:"bytes,45,rep,name=repeated_bytes,json=repeatedBytes" json:"repeated_bytes,omitempty"`
+	Repeatedgroup         []*TestAllTypes_RepeatedGroup `protobuf:"group,46,rep,name=RepeatedGroup,json=repeatedgroup" json:"repeatedgroup,omitempty"`
+	RepeatedNestedMessage []*TestAllTypes_NestedMessage `protobuf:"bytes,48,rep,name=repeated_nested_message,json=repeatedNestedMessage" json:"repeated_nested_message,omitempty"`
+	RepeatedNestedEnum    []TestAllTypes_NestedEnum     `protobuf:"varint,49,rep,name=repeated_nested_enum,json=repeatedNestedEnum,enum=protoclosure.test_pb.TestAllTypes_NestedEnum" json:"repeated_nested_enum,omitempty"`
+	RepeatedInt64Number   []int64                       `protobuf:"varint,52,rep,name=repeated_int64_number,json=repeatedInt64Number" json:"repeated_int64_number,omitempty"`
+	RepeatedInt64String   []int64                       `protobuf:"varint,53,rep,name=repeated_int64_string,json=repeatedInt64String" json:"repeated_int64_string,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+// Default values for TestAllTypes fields.
+const (
+	Default_TestAllTypes_OptionalInt64       = int64(1)
+	Default_TestAllTypes_OptionalFloat       = float32(1.5)
+	Default_TestAllTypes_OptionalInt64Number = int64(9007199254740991)
+	Default_TestAllTypes_OptionalInt64String = int64(9007199254740993)
+)
+
+// Default values for TestAllTypes fields.
+var (
+	Default_TestAllTypes_OptionalBytes = []byte("moo")
+)
+
+func (x *TestAllTypes) Reset() {
+	*x = TestAllTypes{}
+	mi := &file_test_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestAllTypes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestAllTypes) ProtoMessage() {}
+
+func (x *TestAllTypes) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestAllTypes.ProtoReflect.Descriptor instead.
+func (*TestAllTypes) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TestAllTypes) GetOptionalInt32() int32 {
+	if x != nil && x.OptionalInt32 != nil {
+		return *x.OptionalInt32
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalInt64() int64 {
-	if m != nil && m.OptionalInt64 != nil {
-		return *m.OptionalInt64
+func (x *TestAllTypes) GetOptionalInt64() int64 {
+	if x != nil && x.OptionalInt64 != nil {
+		return *x.OptionalInt64
 	}
 	return Default_TestAllTypes_OptionalInt64
 }
 
-func (m *TestAllTypes) GetOptionalUint32() uint32 {
-	if m != nil && m.OptionalUint32 != nil {
-		return *m.OptionalUint32
+func (x *TestAllTypes) GetOptionalUint32() uint32 {
+	if x != nil && x.OptionalUint32 != nil {
+		return *x.OptionalUint32
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalUint64() uint64 {
-	if m != nil && m.OptionalUint64 != nil {
-		return *m.OptionalUint64
+func (x *TestAllTypes) GetOptionalUint64() uint64 {
+	if x != nil && x.OptionalUint64 != nil {
+		return *x.OptionalUint64
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalSint32() int32 {
-	if m != nil && m.OptionalSint32 != nil {
-		return *m.OptionalSint32
+func (x *TestAllTypes) GetOptionalSint32() int32 {
+	if x != nil && x.OptionalSint32 != nil {
+		return *x.OptionalSint32
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalSint64() int64 {
-	if m != nil && m.OptionalSint64 != nil {
-		return *m.OptionalSint64
+func (x *TestAllTypes) GetOptionalSint64() int64 {
+	if x != nil && x.OptionalSint64 != nil {
+		return *x.OptionalSint64
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalFixed32() uint32 {
-	if m != nil && m.OptionalFixed32 != nil {
-		return *m.OptionalFixed32
+func (x *TestAllTypes) GetOptionalFixed32() uint32 {
+	if x != nil && x.OptionalFixed32 != nil {
+		return *x.OptionalFixed32
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalFixed64() uint64 {
-	if m != nil && m.OptionalFixed64 != nil {
-		return *m.OptionalFixed64
+func (x *TestAllTypes) GetOptionalFixed64() uint64 {
+	if x != nil && x.OptionalFixed64 != nil {
+		return *x.OptionalFixed64
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalSfixed32() int32 {
-	if m != nil && m.OptionalSfixed32 != nil {
-		return *m.OptionalSfixed32
+func (x *TestAllTypes) GetOptionalSfixed32() int32 {
+	if x != nil && x.OptionalSfixed32 != nil {
+		return *x.OptionalSfixed32
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalSfixed64() int64 {
-	if m != nil && m.OptionalSfixed64 != nil {
-		return *m.OptionalSfixed64
+func (x *TestAllTypes) GetOptionalSfixed64() int64 {
+	if x != nil && x.OptionalSfixed64 != nil {
+		return *x.OptionalSfixed64
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalFloat() float32 {
-	if m != nil && m.OptionalFloat != nil {
-		return *m.OptionalFloat
+func (x *TestAllTypes) GetOptionalFloat() float32 {
+	if x != nil && x.OptionalFloat != nil {
+		return *x.OptionalFloat
 	}
 	return Default_TestAllTypes_OptionalFloat
 }
 
-func (m *TestAllTypes) GetOptionalDouble() float64 {
-	if m != nil && m.OptionalDouble != nil {
-		return *m.OptionalDouble
+func (x *TestAllTypes) GetOptionalDouble() float64 {
+	if x != nil && x.OptionalDouble != nil {
+		return *x.OptionalDouble
 	}
 	return 0
 }
 
-func (m *TestAllTypes) GetOptionalBool() bool {
-	if m != nil && m.OptionalBool != nil {
-		return *m.OptionalBool
+func (x *TestAllTypes) GetOptionalBool() bool {
+	if x != nil && x.OptionalBool != nil {
+		return *x.OptionalBool
 	}
 	return false
 }
 
-func (m *TestAllTypes) GetOptionalString() string {
-	if m != nil && m.OptionalString != nil {
-		return *m.OptionalString
+func (x *TestAllTypes) GetOptionalString() string {
+	if x != nil && x.OptionalString != nil {
+		return *x.OptionalString
 	}
 	return ""
 }
 
-func (m *TestAllTypes) GetOptionalBytes() []byte {
-	if m != nil && m.OptionalBytes != nil {
-		return m.OptionalBytes
+func (x *TestAllTypes) GetOptionalBytes() []byte {
+	if x != nil && x.OptionalBytes != nil {
+		return x.OptionalBytes
 	}
 	return append([]byte(nil), Default_TestAllTypes_OptionalBytes...)
 }
 
-func (m *TestAllTypes) GetOptionalgroup() *TestAllTypes_OptionalGroup {
-	if m != nil {
-		return m.Optionalgroup
+func (x *TestAllTypes) GetOptionalgroup() *TestAllTypes_OptionalGroup {
+	if x != nil {
+		return x.Optionalgroup
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetOptionalNestedMessage() *TestAllTypes_NestedMessage {
-	if m != nil {
-		return m.OptionalNestedMessage
+func (x *TestAllTypes) GetOptionalNestedMessage() *TestAllTypes_NestedMessage {
+	if x != nil {
+		return x.OptionalNestedMessage
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetOptionalNestedEnum() TestAllTypes_NestedEnum {
-	if m != nil && m.OptionalNestedEnum != nil {
-		return *m.OptionalNestedEnum
+func (x *TestAllTypes) GetOptionalNestedEnum() TestAllTypes_NestedEnum {
+	if x != nil && x.OptionalNestedEnum != nil {
+		return *x.OptionalNestedEnum
 	}
 	return TestAllTypes_FOO
 }
 
-func (m *TestAllTypes) GetOptionalInt64Number() int64 {
-	if m != nil && m.OptionalInt64Number != nil {
-		return *m.OptionalInt64Number
+func (x *TestAllTypes) GetOptionalInt64Number() int64 {
+	if x != nil && x.OptionalInt64Number != nil {
+		return *x.OptionalInt64Number
 	}
 	return Default_TestAllTypes_OptionalInt64Number
 }
 
-func (m *TestAllTypes) GetOptionalInt64String() int64 {
-	if m != nil && m.OptionalInt64String != nil {
-		return *m.OptionalInt64String
+func (x *TestAllTypes) GetOptionalInt64String() int64 {
+	if x != nil && x.OptionalInt64String != nil {
+		return *x.OptionalInt64String
 	}
 	return Default_TestAllTypes_OptionalInt64String
 }
 
-func (m *TestAllTypes) GetRepeatedInt32() []int32 {
-	if m != nil {
-		return m.RepeatedInt32
+func (x *TestAllTypes) GetRepeatedInt32() []int32 {
+	if x != nil {
+		return x.RepeatedInt32
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedInt64() []int64 {
-	if m != nil {
-		return m.RepeatedInt64
+func (x *TestAllTypes) GetRepeatedInt64() []int64 {
+	if x != nil {
+		return x.RepeatedInt64
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedUint32() []uint32 {
-	if m != nil {
-		return m.RepeatedUint32
+func (x *TestAllTypes) GetRepeatedUint32() []uint32 {
+	if x != nil {
+		return x.RepeatedUint32
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedUint64() []uint64 {
-	if m != nil {
-		return m.RepeatedUint64
+func (x *TestAllTypes) GetRepeatedUint64() []uint64 {
+	if x != nil {
+		return x.RepeatedUint64
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedSint32() []int32 {
-	if m != nil {
-		return m.RepeatedSint32
+func (x *TestAllTypes) GetRepeatedSint32() []int32 {
+	if x != nil {
+		return x.RepeatedSint32
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedSint64() []int64 {
-	if m != nil {
-		return m.RepeatedSint64
+func (x *TestAllTypes) GetRepeatedSint64() []int64 {
+	if x != nil {
+		return x.RepeatedSint64
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedFixed32() []uint32 {
-	if m != nil {
-		return m.RepeatedFixed32
+func (x *TestAllTypes) GetRepeatedFixed32() []uint32 {
+	if x != nil {
+		return x.RepeatedFixed32
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedFixed64() []uint64 {
-	if m != nil {
-		return m.RepeatedFixed64
+func (x *TestAllTypes) GetRepeatedFixed64() []uint64 {
+	if x != nil {
+		return x.RepeatedFixed64
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedSfixed32() []int32 {
-	if m != nil {
-		return m.RepeatedSfixed32
+func (x *TestAllTypes) GetRepeatedSfixed32() []int32 {
+	if x != nil {
+		return x.RepeatedSfixed32
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedSfixed64() []int64 {
-	if m != nil {
-		return m.RepeatedSfixed64
+func (x *TestAllTypes) GetRepeatedSfixed64() []int64 {
+	if x != nil {
+		return x.RepeatedSfixed64
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedFloat() []float32 {
-	if m != nil {
-		return m.RepeatedFloat
+func (x *TestAllTypes) GetRepeatedFloat() []float32 {
+	if x != nil {
+		return x.RepeatedFloat
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedDouble() []float64 {
-	if m != nil {
-		return m.RepeatedDouble
+func (x *TestAllTypes) GetRepeatedDouble() []float64 {
+	if x != nil {
+		return x.RepeatedDouble
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedBool() []bool {
-	if m != nil {
-		return m.RepeatedBool
+func (x *TestAllTypes) GetRepeatedBool() []bool {
+	if x != nil {
+		return x.RepeatedBool
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedString() []string {
-	if m != nil {
-		return m.RepeatedString
+func (x *TestAllTypes) GetRepeatedString() []string {
+	if x != nil {
+		return x.RepeatedString
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedBytes() [][]byte {
-	if m != nil {
-		return m.RepeatedBytes
+func (x *TestAllTypes) GetRepeatedBytes() [][]byte {
+	if x != nil {
+		return x.RepeatedBytes
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedgroup() []*TestAllTypes_RepeatedGroup {
-	if m != nil {
-		return m.Repeatedgroup
+func (x *TestAllTypes) GetRepeatedgroup() []*TestAllTypes_RepeatedGroup {
+	if x != nil {
+		return x.Repeatedgroup
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedNestedMessage() []*TestAllTypes_NestedMessage {
-	if m != nil {
-		return m.RepeatedNestedMessage
+func (x *TestAllTypes) GetRepeatedNestedMessage() []*TestAllTypes_NestedMessage {
+	if x != nil {
+		return x.RepeatedNestedMessage
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedNestedEnum() []TestAllTypes_NestedEnum {
-	if m != nil {
-		return m.RepeatedNestedEnum
+func (x *TestAllTypes) GetRepeatedNestedEnum() []TestAllTypes_NestedEnum {
+	if x != nil {
+		return x.RepeatedNestedEnum
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedInt64Number() []int64 {
-	if m != nil {
-		return m.RepeatedInt64Number
+func (x *TestAllTypes) GetRepeatedInt64Number() []int64 {
+	if x != nil {
+		return x.RepeatedInt64Number
 	}
 	return nil
 }
 
-func (m *TestAllTypes) GetRepeatedInt64String() []int64 {
-	if m != nil {
-		return m.RepeatedInt64String
+func (x *TestAllTypes) GetRepeatedInt64String() []int64 {
+	if x != nil {
+		return x.RepeatedInt64String
 	}
 	return nil
 }
 
 type TestAllTypes_NestedMessage struct {
-	B                *int32 `protobuf:"varint,1,opt,name=b" json:"b,omitempty"`
-	C                *int32 `protobuf:"varint,2,opt,name=c" json:"c,omitempty"`
-	XXX_unrecognized []byte `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	B             *int32                 `protobuf:"varint,1,opt,name=b" json:"b,omitempty"`
+	C             *int32                 `protobuf:"varint,2,opt,name=c" json:"c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestAllTypes_NestedMessage) Reset() {
+	*x = TestAllTypes_NestedMessage{}
+	mi := &file_test_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestAllTypes_NestedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestAllTypes_NestedMessage) ProtoMessage() {}
+
+func (x *TestAllTypes_NestedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (m *TestAllTypes_NestedMessage) Reset()         { *m = TestAllTypes_NestedMessage{} }
-func (m *TestAllTypes_NestedMessage) String() string { return proto.CompactTextString(m) }
-func (*TestAllTypes_NestedMessage) ProtoMessage()    {}
+// Deprecated: Use TestAllTypes_NestedMessage.ProtoReflect.Descriptor instead.
+func (*TestAllTypes_NestedMessage) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0, 0}
+}
 
-func (m *TestAllTypes_NestedMessage) GetB() int32 {
-	if m != nil && m.B != nil {
-		return *m.B
+func (x *TestAllTypes_NestedMessage) GetB() int32 {
+	if x != nil && x.B != nil {
+		return *x.B
 	}
 	return 0
 }
 
-func (m *TestAllTypes_NestedMessage) GetC() int32 {
-	if m != nil && m.C != nil {
-		return *m.C
+func (x *TestAllTypes_NestedMessage) GetC() int32 {
+	if x != nil && x.C != nil {
+		return *x.C
 	}
 	return 0
 }
 
 type TestAllTypes_OptionalGroup struct {
-	A                *int32 `protobuf:"varint,17,opt,name=a" json:"a,omitempty"`
-	XXX_unrecognized []byte `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	A             *int32                 `protobuf:"varint,17,opt,name=a" json:"a,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestAllTypes_OptionalGroup) Reset() {
+	*x = TestAllTypes_OptionalGroup{}
+	mi := &file_test_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestAllTypes_OptionalGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (m *TestAllTypes_OptionalGroup) Reset()         { *m = TestAllTypes_OptionalGroup{} }
-func (m *TestAllTypes_OptionalGroup) String() string { return proto.CompactTextString(m) }
-func (*TestAllTypes_OptionalGroup) ProtoMessage()    {}
+func (*TestAllTypes_OptionalGroup) ProtoMessage() {}
 
-func (m *TestAllTypes_OptionalGroup) GetA() int32 {
-	if m != nil && m.A != nil {
-		return *m.A
+func (x *TestAllTypes_OptionalGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestAllTypes_OptionalGroup.ProtoReflect.Descriptor instead.
+func (*TestAllTypes_OptionalGroup) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *TestAllTypes_OptionalGroup) GetA() int32 {
+	if x != nil && x.A != nil {
+		return *x.A
 	}
 	return 0
 }
 
 type TestAllTypes_RepeatedGroup struct {
-	A                []int32 `protobuf:"varint,47,rep,name=a" json:"a,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	A             []int32                `protobuf:"varint,47,rep,name=a" json:"a,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TestAllTypes_RepeatedGroup) Reset() {
+	*x = TestAllTypes_RepeatedGroup{}
+	mi := &file_test_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestAllTypes_RepeatedGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestAllTypes_RepeatedGroup) ProtoMessage() {}
+
+func (x *TestAllTypes_RepeatedGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (m *TestAllTypes_RepeatedGroup) Reset()         { *m = TestAllTypes_RepeatedGroup{} }
-func (m *TestAllTypes_RepeatedGroup) String() string { return proto.CompactTextString(m) }
-func (*TestAllTypes_RepeatedGroup) ProtoMessage()    {}
+// Deprecated: Use TestAllTypes_RepeatedGroup.ProtoReflect.Descriptor instead.
+func (*TestAllTypes_RepeatedGroup) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0, 2}
+}
 
-func (m *TestAllTypes_RepeatedGroup) GetA() []int32 {
-	if m != nil {
-		return m.A
+func (x *TestAllTypes_RepeatedGroup) GetA() []int32 {
+	if x != nil {
+		return x.A
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterEnum("TestAllTypes_NestedEnum", TestAllTypes_NestedEnum_name, TestAllTypes_NestedEnum_value)
+var File_test_proto protoreflect.FileDescriptor
+
+const file_test_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"test.proto\x12\x14protoclosure.test_pb\"\xc7\x11\n" +
+	"\fTestAllTypes\x12%\n" +
+	"\x0eoptional_int32\x18\x01 \x01(\x05R\roptionalInt32\x12(\n" +
+	"\x0eoptional_int64\x18\x02 \x01(\x03:\x011R\roptionalInt64\x12'\n" +
+	"\x0foptional_uint32\x18\x03 \x01(\rR\x0eoptionalUint32\x12'\n" +
+	"\x0foptional_uint64\x18\x04 \x01(\x04R\x0eoptionalUint64\x12'\n" +
+	"\x0foptional_sint32\x18\x05 \x01(\x11R\x0eoptionalSint32\x12'\n" +
+	"\x0foptional_sint64\x18\x06 \x01(\x12R\x0eoptionalSint64\x12)\n" +
+	"\x10optional_fixed32\x18\a \x01(\aR\x0foptionalFixed32\x12)\n" +
+	"\x10optional_fixed64\x18\b \x01(\x06R\x0foptionalFixed64\x12+\n" +
+	"\x11optional_sfixed32\x18\t \x01(\x0fR\x10optionalSfixed32\x12+\n" +
+	"\x11optional_sfixed64\x18\n" +
+	" \x01(\x10R\x10optionalSfixed64\x12*\n" +
+	"\x0eoptional_float\x18\v \x01(\x02:\x031.5R\roptionalFloat\x12'\n" +
+	"\x0foptional_double\x18\f \x01(\x01R\x0eoptionalDouble\x12#\n" +
+	"\roptional_bool\x18\r \x01(\bR\foptionalBool\x12'\n" +
+	"\x0foptional_string\x18\x0e \x01(\tR\x0eoptionalString\x12*\n" +
+	"\x0eoptional_bytes\x18\x0f \x01(\f:\x03mooR\roptionalBytes\x12V\n" +
+	"\roptionalgroup\x18\x10 \x01(\n" +
+	"20.protoclosure.test_pb.TestAllTypes.OptionalGroupR\roptionalgroup\x12h\n" +
+	"\x17optional_nested_message\x18\x12 \x01(\v20.protoclosure.test_pb.TestAllTypes.NestedMessageR\x15optionalNestedMessage\x12_\n" +
+	"\x14optional_nested_enum\x18\x15 \x01(\x0e2-.protoclosure.test_pb.TestAllTypes.NestedEnumR\x12optionalNestedEnum\x12H\n" +
+	"\x15optional_int64_number\x182 \x01(\x03:\x109007199254740991B\x020\x02R\x13optionalInt64Number\x12H\n" +
+	"\x15optional_int64_string\x183 \x01(\x03:\x109007199254740993B\x020\x01R\x13optionalInt64String\x12%\n" +
+	"\x0erepeated_int32\x18\x1f \x03(\x05R\rrepeatedInt32\x12%\n" +
+	"\x0erepeated_int64\x18  \x03(\x03R\rrepeatedInt64\x12'\n" +
+	"\x0frepeated_uint32\x18! \x03(\rR\x0erepeatedUint32\x12'\n" +
+	"\x0frepeated_uint64\x18\" \x03(\x04R\x0erepeatedUint64\x12'\n" +
+	"\x0frepeated_sint32\x18# \x03(\x11R\x0erepeatedSint32\x12'\n" +
+	"\x0frepeated_sint64\x18$ \x03(\x12R\x0erepeatedSint64\x12)\n" +
+	"\x10repeated_fixed32\x18% \x03(\aR\x0frepeatedFixed32\x12)\n" +
+	"\x10repeated_fixed64\x18& \x03(\x06R\x0frepeatedFixed64\x12+\n" +
+	"\x11repeated_sfixed32\x18' \x03(\x0fR\x10repeatedSfixed32\x12+\n" +
+	"\x11repeated_sfixed64\x18( \x03(\x10R\x10repeatedSfixed64\x12%\n" +
+	"\x0erepeated_float\x18) \x03(\x02R\rrepeatedFloat\x12'\n" +
+	"\x0frepeated_double\x18* \x03(\x01R\x0erepeatedDouble\x12#\n" +
+	"\rrepeated_bool\x18+ \x03(\bR\frepeatedBool\x12'\n" +
+	"\x0frepeated_string\x18, \x03(\tR\x0erepeatedString\x12%\n" +
+	"\x0erepeated_bytes\x18- \x03(\fR\rrepeatedBytes\x12V\n" +
+	"\rrepeatedgroup\x18. \x03(\n" +
+	"20.protoclosure.test_pb.TestAllTypes.RepeatedGroupR\rrepeatedgroup\x12h\n" +
+	"\x17repeated_nested_message\x180 \x03(\v20.protoclosure.test_pb.TestAllTypes.NestedMessageR\x15repeatedNestedMessage\x12_\n" +
+	"\x14repeated_nested_enum\x181 \x03(\x0e2-.protoclosure.test_pb.TestAllTypes.NestedEnumR\x12repeatedNestedEnum\x126\n" +
+	"\x15repeated_int64_number\x184 \x03(\x03B\x020\x02R\x13repeatedInt64Number\x126\n" +
+	"\x15repeated_int64_string\x185 \x03(\x03B\x020\x01R\x13repeatedInt64String\x1a+\n" +
+	"\rNestedMessage\x12\f\n" +
+	"\x01b\x18\x01 \x01(\x05R\x01b\x12\f\n" +
+	"\x01c\x18\x02 \x01(\x05R\x01c\x1a\x1d\n" +
+	"\rOptionalGroup\x12\f\n" +
+	"\x01a\x18\x11 \x01(\x05R\x01a\x1a\x1d\n" +
+	"\rRepeatedGroup\x12\f\n" +
+	"\x01a\x18/ \x03(\x05R\x01a\"'\n" +
+	"\n" +
+	"NestedEnum\x12\a\n" +
+	"\x03FOO\x10\x00\x12\a\n" +
+	"\x03BAR\x10\x02\x12\a\n" +
+	"\x03BAZ\x10\x03B\x16Z\x14protoclosure/test_pb"
+
+var (
+	file_test_proto_rawDescOnce sync.Once
+	file_test_proto_rawDescData []byte
+)
+
+func file_test_proto_rawDescGZIP() []byte {
+	file_test_proto_rawDescOnce.Do(func() {
+		file_test_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_test_proto_rawDesc), len(file_test_proto_rawDesc)))
+	})
+	return file_test_proto_rawDescData
+}
+
+var file_test_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_test_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_test_proto_goTypes = []any{
+	(TestAllTypes_NestedEnum)(0),       // 0: protoclosure.test_pb.TestAllTypes.NestedEnum
+	(*TestAllTypes)(nil),               // 1: protoclosure.test_pb.TestAllTypes
+	(*TestAllTypes_NestedMessage)(nil), // 2: protoclosure.test_pb.TestAllTypes.NestedMessage
+	(*TestAllTypes_OptionalGroup)(nil), // 3: protoclosure.test_pb.TestAllTypes.OptionalGroup
+	(*TestAllTypes_RepeatedGroup)(nil), // 4: protoclosure.test_pb.TestAllTypes.RepeatedGroup
+}
+var file_test_proto_depIdxs = []int32{
+	3, // 0: protoclosure.test_pb.TestAllTypes.optionalgroup:type_name -> protoclosure.test_pb.TestAllTypes.OptionalGroup
+	2, // 1: protoclosure.test_pb.TestAllTypes.optional_nested_message:type_name -> protoclosure.test_pb.TestAllTypes.NestedMessage
+	0, // 2: protoclosure.test_pb.TestAllTypes.optional_nested_enum:type_name -> protoclosure.test_pb.TestAllTypes.NestedEnum
+	4, // 3: protoclosure.test_pb.TestAllTypes.repeatedgroup:type_name -> protoclosure.test_pb.TestAllTypes.RepeatedGroup
+	2, // 4: protoclosure.test_pb.TestAllTypes.repeated_nested_message:type_name -> protoclosure.test_pb.TestAllTypes.NestedMessage
+	0, // 5: protoclosure.test_pb.TestAllTypes.repeated_nested_enum:type_name -> protoclosure.test_pb.TestAllTypes.NestedEnum
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_test_proto_init() }
+func file_test_proto_init() {
+	if File_test_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_test_proto_rawDesc), len(file_test_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_test_proto_goTypes,
+		DependencyIndexes: file_test_proto_depIdxs,
+		EnumInfos:         file_test_proto_enumTypes,
+		MessageInfos:      file_test_proto_msgTypes,
+	}.Build()
+	File_test_proto = out.File
+	file_test_proto_goTypes = nil
+	file_test_proto_depIdxs = nil
 }
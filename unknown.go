@@ -0,0 +1,203 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Unknown JSON entries are kept in the unknown fields of the message so that
+// they survive a decode/encode round trip. They are stored together as a
+// single JSON record under unknownJSONTag, never under their own tag numbers,
+// so that a binary encoding of the message does not present them to readers
+// as values of real fields. Readers that do not know the record skip it like
+// any other unknown field.
+const unknownJSONTag = protowire.MaxValidNumber
+
+// maxUnknownTagGap is how far beyond the largest tag number of a message's
+// fields the PBLite format writes unknown fields. Each tag number is an array
+// index, so a single unknown field with a large tag number would otherwise
+// produce an array of that length.
+const maxUnknownTagGap = 1024
+
+// unknownJSON holds the unknown fields of a message as JSON values.
+type unknownJSON struct {
+	tagged map[int]interface{}
+	named  map[string]interface{}
+}
+
+// unknownJSONRecord is the form in which unknownJSON is stored.
+type unknownJSONRecord struct {
+	Tagged map[int]interface{}    `json:"tagged,omitempty"`
+	Named  map[string]interface{} `json:"named,omitempty"`
+}
+
+// maxTag returns the largest tag number of the tagged entries, or -1.
+func (uj *unknownJSON) maxTag() int {
+	maxTag := -1
+	for tag := range uj.tagged {
+		if tag > maxTag {
+			maxTag = tag
+		}
+	}
+	return maxTag
+}
+
+// getUnknownJSON converts the unknown fields of msg into JSON values. Fields
+// that were not stored from JSON, such as those read from the binary format,
+// are written using the JSON type closest to their wire type, with varint and
+// fixed64 values as decimal strings like other 64-bit integers.
+func getUnknownJSON(msg protoreflect.Message) (*unknownJSON, error) {
+	uj := &unknownJSON{}
+	var record *unknownJSONRecord
+	err := rangeUnknown(msg.GetUnknown(), func(num protowire.Number, typ protowire.Type, b []byte) error {
+		var v interface{}
+		switch typ {
+		case protowire.VarintType:
+			x, _ := protowire.ConsumeVarint(b)
+			v = strconv.FormatUint(x, 10)
+		case protowire.Fixed32Type:
+			x, _ := protowire.ConsumeFixed32(b)
+			v = x
+		case protowire.Fixed64Type:
+			x, _ := protowire.ConsumeFixed64(b)
+			v = strconv.FormatUint(x, 10)
+		case protowire.BytesType:
+			x, _ := protowire.ConsumeBytes(b)
+			if num == unknownJSONTag {
+				record = &unknownJSONRecord{}
+				return unmarshalJSON(x, record)
+			}
+			v = string(x)
+		default:
+			// groups have no JSON representation
+			return nil
+		}
+
+		if uj.tagged == nil {
+			uj.tagged = make(map[int]interface{})
+		}
+		// repeated occurrences of the same tag are written as an array
+		if prev, ok := uj.tagged[int(num)]; ok {
+			if items, ok := prev.([]interface{}); ok {
+				v = append(items, v)
+			} else {
+				v = []interface{}{prev, v}
+			}
+		}
+		uj.tagged[int(num)] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// entries stored from JSON take precedence
+	if record != nil {
+		for tag, v := range record.Tagged {
+			if uj.tagged == nil {
+				uj.tagged = make(map[int]interface{})
+			}
+			uj.tagged[tag] = v
+		}
+		uj.named = record.Named
+	}
+	return uj, nil
+}
+
+// addUnknownJSON stores the JSON values of unknown entries, keyed by tag
+// number or by unrecognised field name, in the unknown fields of msg. Values
+// already stored under the same keys are replaced.
+func addUnknownJSON(msg protoreflect.Message, tagged map[int]interface{}, named map[string]interface{}) error {
+	if len(tagged) == 0 && len(named) == 0 {
+		return nil
+	}
+
+	// merge with the record of an earlier decode, keeping other fields
+	record := &unknownJSONRecord{}
+	var other []byte
+	err := rangeUnknown(msg.GetUnknown(), func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if num == unknownJSONTag && typ == protowire.BytesType {
+			x, _ := protowire.ConsumeBytes(b)
+			return unmarshalJSON(x, record)
+		}
+		other = protowire.AppendTag(other, num, typ)
+		other = append(other, b...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for tag, v := range tagged {
+		if record.Tagged == nil {
+			record.Tagged = make(map[int]interface{})
+		}
+		record.Tagged[tag] = v
+	}
+	for k, v := range named {
+		if record.Named == nil {
+			record.Named = make(map[string]interface{})
+		}
+		record.Named[k] = v
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	b := protowire.AppendTag(other, unknownJSONTag, protowire.BytesType)
+	b = protowire.AppendBytes(b, raw)
+	msg.SetUnknown(b)
+	return nil
+}
+
+// mergeUnknownJSON stores the unknown JSON entries kept in src in msg too,
+// replacing those with the same keys.
+func mergeUnknownJSON(msg, src protoreflect.Message) error {
+	if len(src.GetUnknown()) == 0 {
+		return nil
+	}
+	uj, err := getUnknownJSON(src)
+	if err != nil {
+		return err
+	}
+	return addUnknownJSON(msg, uj.tagged, uj.named)
+}
+
+// rangeUnknown calls f for each field in the unknown field bytes b with the
+// encoded field value following its tag.
+func rangeUnknown(b []byte, f func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		err := f(num, typ, b[:n])
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// unknownTag returns the tag number of the Object JSON key k when keyed by
+// tag number, or -1 if k is not a tag number.
+func unknownTag(k string) int {
+	tag, err := strconv.Atoi(k)
+	if err != nil || tag < 1 || tag > int(protowire.MaxValidNumber) {
+		return -1
+	}
+	return tag
+}
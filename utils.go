@@ -5,350 +5,420 @@
 package protoclosure
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"reflect"
+	"math"
+	"sort"
 	"strconv"
 
-	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-var (
-	typeOfSliceBool    = reflect.TypeOf([]bool{})
-	typeOfSliceUint8   = reflect.TypeOf([]uint8{})
-	typeOfSliceFloat32 = reflect.TypeOf([]float32{})
-	typeOfSliceFloat64 = reflect.TypeOf([]float64{})
-	typeOfSliceUint32  = reflect.TypeOf([]uint32{})
-	typeOfSliceUint64  = reflect.TypeOf([]uint64{})
-	typeOfSliceInt32   = reflect.TypeOf([]int32{})
-	typeOfSliceInt64   = reflect.TypeOf([]int64{})
-	typeOfSliceString  = reflect.TypeOf([]string{})
-
-	typeOfMessage = reflect.TypeOf((*proto.Message)(nil)).Elem()
-	typeOfString  = reflect.TypeOf("")
-	typeOfUint64  = reflect.TypeOf(uint64(0))
-	typeOfBool    = reflect.TypeOf(true)
-	typeOfInt64   = reflect.TypeOf(int64(0))
-)
-
-func setPBFieldPtr(fv *reflect.Value, v interface{}) error {
-	newFV := reflect.New(fv.Type().Elem())
-
-	var err error
-	switch fv.Type().Elem() {
-	case typeOfInt64:
-		switch vt := v.(type) {
-		case float64:
-			// legal conversion
-		case string:
-			// legal conversion
-			v, err = strconv.ParseInt(vt, 10, 64)
-			if err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("Cannot convert %T to %v", v, fv.Type().Elem())
+// fromProtoScalar converts a singular, non-message protocol buffer value into
+// the value written to the JSON representation. 64-bit integers are written
+// as decimal strings unless numEnc is set, bytes are written with bytesEnc if
+// it is not nil, and google.protobuf.NullValue as null if wellKnownTypes is
+// set.
+func fromProtoScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value, numEnc bool, bytesEnc *base64.Encoding, wellKnownTypes bool) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed64Kind:
+		if numEnc {
+			return v.Int()
 		}
-
-	case typeOfUint64:
-		switch vt := v.(type) {
-		case float64:
-			// legal conversion
-		case string:
-			// legal conversion
-			v, err = strconv.ParseUint(vt, 10, 64)
-			if err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("Cannot convert %T to %v", v, fv.Type().Elem())
+		return strconv.FormatInt(v.Int(), 10)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if numEnc {
+			return v.Uint()
 		}
-
-	case typeOfBool:
-		switch vt := v.(type) {
-		case bool:
-			// legal conversion
-		case float64:
-			// legal conversion
-			v = vt != 0
-		default:
-			return fmt.Errorf("Cannot convert %T to %v", v, fv.Type().Elem())
+		return strconv.FormatUint(v.Uint(), 10)
+	case protoreflect.EnumKind:
+		if wellKnownTypes && isNullValue(fd) {
+			return nil
 		}
-
-	case typeOfString:
-		switch v.(type) {
-		case string:
-			// legal conversion
-		default:
-			return fmt.Errorf("Cannot convert %T to %v", v, fv.Type().Elem())
+		return int32(v.Enum())
+	case protoreflect.FloatKind:
+		return float32(v.Float())
+	case protoreflect.BytesKind:
+		if bytesEnc != nil {
+			return bytesEnc.EncodeToString(v.Bytes())
 		}
-
+		return string(v.Bytes())
 	default:
-		switch v.(type) {
-		case float64:
-			// legal conversion
-		default:
-			return fmt.Errorf("Cannot convert %T to %v", v, fv.Type().Elem())
-		}
+		return v.Interface()
 	}
+}
 
-	fve := reflect.ValueOf(v).Convert(fv.Type().Elem())
-	newFV.Elem().Set(fve)
-	fv.Set(newFV)
-	return nil
+// decodeOptions are the unmarshaler options used when converting decoded
+// JSON values into protocol buffer values.
+type decodeOptions struct {
+	allErrors         bool
+	allowLossyNumbers bool
+	bytesEncoding     *base64.Encoding
+	wellKnownTypes    bool
 }
 
-func setPBFieldSlice(fv *reflect.Value, v interface{}) error {
-	if vt, ok := v.([]interface{}); ok && len(vt) == 0 {
-		return nil
-	}
+var (
+	errNotInteger      = errors.New("number is not an integer")
+	errInexact         = errors.New("number cannot be represented exactly")
+	errUnknownEnumName = errors.New("unknown enum value name")
+)
 
-	newFV := reflect.MakeSlice(fv.Type(), 0, 0)
+// maxSafeInteger is the magnitude up to which float64 represents integers
+// unambiguously, the same as Number.MAX_SAFE_INTEGER in JS.
+const maxSafeInteger = 1<<53 - 1
 
-	// Special case repeated enums
-	if fv.Type().Elem().Kind() == reflect.Int32 {
-		vt, ok := v.([]interface{})
-		if !ok {
-			return fmt.Errorf("Cannot convert %T to []enum/int32", vt)
-		}
+// checkInteger returns an error if f is not an integer in the range
+// [min, max) that float64 represents unambiguously.
+func checkInteger(f, min, max float64) error {
+	if f != math.Trunc(f) {
+		return errNotInteger
+	}
+	if f < min || f >= max {
+		return strconv.ErrRange
+	}
+	if math.Abs(f) > maxSafeInteger {
+		return errInexact
+	}
+	return nil
+}
 
-		// legal conversion
-		vint32, err := toSliceInt32(vt)
+// toProtoInt parses the JSON number n for a signed integer field of the
+// given bit size. Integers are parsed exactly; numbers written with a
+// fraction or exponent must be integral and within the safe float64 range.
+func toProtoInt(n json.Number, bitSize int, opts decodeOptions) (int64, error) {
+	i, err := strconv.ParseInt(n.String(), 10, bitSize)
+	if err == nil || errors.Is(err, strconv.ErrRange) && !opts.allowLossyNumbers {
+		return i, err
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return 0, err
+	}
+	if !opts.allowLossyNumbers {
+		limit := math.Ldexp(1, bitSize-1)
+		err = checkInteger(f, -limit, limit)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		for _, item := range vint32 {
-			newFV = reflect.Append(newFV, reflect.ValueOf(item).Convert(fv.Type().Elem()))
+	}
+	return int64(f), nil
+}
+
+// toProtoUint parses the JSON number n for an unsigned integer field of the
+// given bit size, following the same rules as toProtoInt.
+func toProtoUint(n json.Number, bitSize int, opts decodeOptions) (uint64, error) {
+	ui, err := strconv.ParseUint(n.String(), 10, bitSize)
+	if err == nil || errors.Is(err, strconv.ErrRange) && !opts.allowLossyNumbers {
+		return ui, err
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return 0, err
+	}
+	if !opts.allowLossyNumbers {
+		err = checkInteger(f, 0, math.Ldexp(1, bitSize))
+		if err != nil {
+			return 0, err
 		}
-		fv.Set(newFV)
-		return nil
+	}
+	if f < 0 {
+		// wrap negative numbers
+		return uint64(int64(f)), nil
+	}
+	return uint64(f), nil
+}
+
+// toProtoScalar converts a decoded JSON value into a singular, non-message
+// protocol buffer value for field fd. JSON numbers are expected as
+// json.Number. Numbers that are fractional, out of range or inexact for
+// integer fields are rejected unless opts.allowLossyNumbers is set.
+func toProtoScalar(fd protoreflect.FieldDescriptor, v interface{}, opts decodeOptions) (protoreflect.Value, error) {
+	if v == nil && opts.wellKnownTypes && isNullValue(fd) {
+		return protoreflect.ValueOfEnum(0), nil
 	}
 
 	var err error
-	switch fv.Type() {
-	case typeOfSliceUint8:
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed64Kind:
 		switch vt := v.(type) {
+		case json.Number:
+			var i64 int64
+			i64, err = toProtoInt(vt, 64, opts)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfInt64(i64), nil
+			}
 		case string:
-			// legal conversion
-			v = []uint8(vt)
-		default:
-			return fmt.Errorf("Unable to set Bytes value from %T", v)
+			var i64 int64
+			i64, err = strconv.ParseInt(vt, 10, 64)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfInt64(i64), nil
+			}
 		}
 
-	case typeOfSliceInt32:
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
 		switch vt := v.(type) {
-		case []interface{}:
-			// legal conversion
-			v, err = toSliceInt32(vt)
-			if err != nil {
-				return err
+		case json.Number:
+			var ui64 uint64
+			ui64, err = toProtoUint(vt, 64, opts)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfUint64(ui64), nil
+			}
+		case string:
+			var ui64 uint64
+			ui64, err = strconv.ParseUint(vt, 10, 64)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfUint64(ui64), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []int32", vt)
 		}
 
-	case typeOfSliceInt64:
-		switch vt := v.(type) {
-		case []interface{}:
-			// legal conversion
-			v, err = toSliceInt64(vt)
-			if err != nil {
-				return err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind,
+		protoreflect.Sfixed32Kind:
+		if vt, ok := v.(json.Number); ok {
+			var i64 int64
+			i64, err = toProtoInt(vt, 32, opts)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfInt32(int32(i64)), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []int64", vt)
 		}
 
-	case typeOfSliceUint32:
-		switch vt := v.(type) {
-		case []interface{}:
-			// legal conversion
-			v, err = toSliceUint32(vt)
-			if err != nil {
-				return err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if vt, ok := v.(json.Number); ok {
+			var ui64 uint64
+			ui64, err = toProtoUint(vt, 32, opts)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfUint32(uint32(ui64)), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []uint32", vt)
 		}
 
-	case typeOfSliceUint64:
+	case protoreflect.EnumKind:
 		switch vt := v.(type) {
-		case []interface{}:
-			// legal conversion
-			v, err = toSliceUint64(vt)
-			if err != nil {
-				return err
+		case json.Number:
+			var i64 int64
+			i64, err = toProtoInt(vt, 32, opts)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i64)), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []uint64", vt)
+		case string:
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(vt)); ev != nil {
+				// legal conversion
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+			err = errUnknownEnumName
 		}
 
-	case typeOfSliceFloat32:
-		switch vt := v.(type) {
-		case []interface{}:
-			// legal conversion
-			v, err = toSliceFloat32(vt)
-			if err != nil {
-				return err
+	case protoreflect.FloatKind:
+		if vt, ok := v.(json.Number); ok {
+			var f64 float64
+			f64, err = vt.Float64()
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfFloat32(float32(f64)), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []float32", vt)
 		}
 
-	case typeOfSliceFloat64:
-		switch vt := v.(type) {
-		case []interface{}:
-			// legal conversion
-			v, err = toSliceFloat64(vt)
-			if err != nil {
-				return err
+	case protoreflect.DoubleKind:
+		if vt, ok := v.(json.Number); ok {
+			var f64 float64
+			f64, err = vt.Float64()
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfFloat64(f64), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []float64", vt)
 		}
 
-	case typeOfSliceBool:
+	case protoreflect.BoolKind:
 		switch vt := v.(type) {
-		case []interface{}:
+		case bool:
 			// legal conversion
-			v, err = toSliceBool(vt)
-			if err != nil {
-				return err
+			return protoreflect.ValueOfBool(vt), nil
+		case json.Number:
+			var f64 float64
+			f64, err = vt.Float64()
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfBool(f64 != 0), nil
 			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []bool", vt)
 		}
 
-	case typeOfSliceString:
-		switch vt := v.(type) {
-		case []interface{}:
+	case protoreflect.StringKind:
+		if vt, ok := v.(string); ok {
 			// legal conversion
-			v, err = toSliceString(vt)
-			if err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("Cannot convert %T to []string", vt)
+			return protoreflect.ValueOfString(vt), nil
 		}
 
-	default:
-		return fmt.Errorf("Unsupported slice type: %v", fv.Type())
+	case protoreflect.BytesKind:
+		if vt, ok := v.(string); ok {
+			if opts.bytesEncoding == nil {
+				// legal conversion
+				return protoreflect.ValueOfBytes([]byte(vt)), nil
+			}
+			var b []byte
+			b, err = opts.bytesEncoding.DecodeString(vt)
+			if err == nil {
+				// legal conversion
+				return protoreflect.ValueOfBytes(b), nil
+			}
+		}
 	}
-
-	vo := reflect.ValueOf(v)
-	fv.Set(reflect.AppendSlice(newFV, vo))
-	return nil
+	return protoreflect.Value{}, newDecodeError(v, kindName(fd), err)
 }
 
-func toSliceFloat32(s []interface{}) ([]float32, error) {
-	d := []float32{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case float64:
-			d = append(d, float32(vt))
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
-		}
+// setProtoList replaces the repeated, non-message field fd of m with the
+// elements of the decoded JSON array v. If opts.allErrors is set, elements
+// that cannot be converted are skipped and all of their errors returned.
+func setProtoList(m protoreflect.Message, fd protoreflect.FieldDescriptor, v interface{}, opts decodeOptions) error {
+	vt, ok := v.([]interface{})
+	if !ok {
+		return newDecodeError(v, typeName(fd), nil)
+	}
+	if len(vt) == 0 {
+		return nil
 	}
-	return d, nil
-}
 
-func toSliceFloat64(s []interface{}) ([]float64, error) {
-	d := []float64{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case float64:
-			d = append(d, vt)
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
+	var errs DecodeErrors
+	lv := m.NewField(fd)
+	list := lv.List()
+	for i, item := range vt {
+		pv, err := toProtoScalar(fd, item, opts)
+		if err != nil {
+			err = atIndex(err, i)
+			if !opts.allErrors {
+				return err
+			}
+			errs = errs.add(err)
+			continue
 		}
+		list.Append(pv)
 	}
-	return d, nil
+	if list.Len() > 0 {
+		m.Set(fd, lv)
+	}
+	return errs.err()
 }
 
-func toSliceInt32(s []interface{}) ([]int32, error) {
-	d := []int32{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case float64:
-			d = append(d, int32(vt))
+// sortedMapKeys returns the keys of mp in ascending order so that map fields
+// are encoded deterministically.
+func sortedMapKeys(mp protoreflect.Map) []protoreflect.MapKey {
+	keys := make([]protoreflect.MapKey, 0, mp.Len())
+	mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		switch a := keys[i].Interface().(type) {
+		case bool:
+			return !a && keys[j].Bool()
+		case int32, int64:
+			return keys[i].Int() < keys[j].Int()
+		case uint32, uint64:
+			return keys[i].Uint() < keys[j].Uint()
 		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
+			return keys[i].String() < keys[j].String()
 		}
-	}
-	return d, nil
+	})
+	return keys
 }
 
-func toSliceInt64(s []interface{}) ([]int64, error) {
-	d := []int64{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case string:
-			i64, err := strconv.ParseInt(vt, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-			d = append(d, i64)
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
+// toProtoMapKey parses the Object JSON key s into a map key of the type of
+// field fd.
+func toProtoMapKey(fd protoreflect.FieldDescriptor, s string) (protoreflect.MapKey, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return protoreflect.MapKey{}, newDecodeError(s, kindName(fd), err)
 		}
-	}
-	return d, nil
-}
+		return protoreflect.ValueOfBool(b).MapKey(), nil
 
-func toSliceUint32(s []interface{}) ([]uint32, error) {
-	d := []uint32{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case float64:
-			d = append(d, uint32(vt))
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind,
+		protoreflect.Sfixed32Kind:
+		i32, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, newDecodeError(s, kindName(fd), err)
 		}
+		return protoreflect.ValueOfInt32(int32(i32)).MapKey(), nil
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed64Kind:
+		i64, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, newDecodeError(s, kindName(fd), err)
+		}
+		return protoreflect.ValueOfInt64(i64).MapKey(), nil
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		ui32, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, newDecodeError(s, kindName(fd), err)
+		}
+		return protoreflect.ValueOfUint32(uint32(ui32)).MapKey(), nil
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		ui64, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, newDecodeError(s, kindName(fd), err)
+		}
+		return protoreflect.ValueOfUint64(ui64).MapKey(), nil
+
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s).MapKey(), nil
+
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("Unsupported map key type: %v", fd.Kind())
 	}
-	return d, nil
 }
 
-func toSliceUint64(s []interface{}) ([]uint64, error) {
-	d := []uint64{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case string:
-			ui64, err := strconv.ParseUint(vt, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-			d = append(d, ui64)
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
-		}
+// hasDefault reports whether fd is a singular, non-message field whose value
+// is meaningful when unset: a proto2 field with a declared default, or a
+// proto3 field without explicit presence, whose default is the zero value.
+// Oneof members are excluded, as at most one of them may be set.
+func hasDefault(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.ContainingOneof() != nil {
+		return false
 	}
-	return d, nil
+	return fd.HasDefault() || !fd.HasPresence()
 }
 
-func toSliceBool(s []interface{}) ([]bool, error) {
-	d := []bool{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case bool:
-			d = append(d, vt)
-		case float64:
-			d = append(d, vt != 0)
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
+// setDefaults sets every unset field of msg that declares a default value,
+// other than oneof members, to that value.
+func setDefaults(msg protoreflect.Message) {
+	for _, fi := range getMessageInfo(msg.Descriptor()).fields {
+		fd := fi.fd
+		if fd.HasDefault() && fd.ContainingOneof() == nil && !msg.Has(fd) {
+			msg.Set(fd, fd.Default())
 		}
 	}
-	return d, nil
 }
 
-func toSliceString(s []interface{}) ([]string, error) {
-	d := []string{}
-	for _, v := range s {
-		switch vt := v.(type) {
-		case string:
-			d = append(d, vt)
-		default:
-			return nil, fmt.Errorf("Illegal type in slice: %T", v)
-		}
+// oneofSet records which member of each oneof has been decoded so that
+// conflicting members in the JSON input are reported.
+type oneofSet map[protoreflect.OneofDescriptor]protoreflect.FieldDescriptor
+
+// add records that fd was present in the input, returning an error if
+// another member of the same oneof was already present.
+func (s *oneofSet) add(fd protoreflect.FieldDescriptor) error {
+	od := fd.ContainingOneof()
+	if od == nil || od.IsSynthetic() {
+		return nil
+	}
+	if *s == nil {
+		*s = make(oneofSet)
 	}
-	return d, nil
+	if prev, ok := (*s)[od]; ok {
+		return fmt.Errorf("Multiple fields set for oneof %v: %v and %v",
+			od.Name(), prev.Name(), fd.Name())
+	}
+	(*s)[od] = fd
+	return nil
 }
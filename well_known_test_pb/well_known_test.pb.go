@@ -0,0 +1,379 @@
+// A proto3 file used for unit testing well-known type encodings.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: well_known_test.proto
+
+package well_known_test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TestWellKnownTypes struct {
+	state               protoimpl.MessageState          `protogen:"open.v1"`
+	OptionalTimestamp   *timestamppb.Timestamp          `protobuf:"bytes,1,opt,name=optional_timestamp,json=optionalTimestamp,proto3" json:"optional_timestamp,omitempty"`
+	OptionalDuration    *durationpb.Duration            `protobuf:"bytes,2,opt,name=optional_duration,json=optionalDuration,proto3" json:"optional_duration,omitempty"`
+	OptionalDoubleValue *wrapperspb.DoubleValue         `protobuf:"bytes,3,opt,name=optional_double_value,json=optionalDoubleValue,proto3" json:"optional_double_value,omitempty"`
+	OptionalFloatValue  *wrapperspb.FloatValue          `protobuf:"bytes,4,opt,name=optional_float_value,json=optionalFloatValue,proto3" json:"optional_float_value,omitempty"`
+	OptionalInt64Value  *wrapperspb.Int64Value          `protobuf:"bytes,5,opt,name=optional_int64_value,json=optionalInt64Value,proto3" json:"optional_int64_value,omitempty"`
+	OptionalUint64Value *wrapperspb.UInt64Value         `protobuf:"bytes,6,opt,name=optional_uint64_value,json=optionalUint64Value,proto3" json:"optional_uint64_value,omitempty"`
+	OptionalInt32Value  *wrapperspb.Int32Value          `protobuf:"bytes,7,opt,name=optional_int32_value,json=optionalInt32Value,proto3" json:"optional_int32_value,omitempty"`
+	OptionalUint32Value *wrapperspb.UInt32Value         `protobuf:"bytes,8,opt,name=optional_uint32_value,json=optionalUint32Value,proto3" json:"optional_uint32_value,omitempty"`
+	OptionalBoolValue   *wrapperspb.BoolValue           `protobuf:"bytes,9,opt,name=optional_bool_value,json=optionalBoolValue,proto3" json:"optional_bool_value,omitempty"`
+	OptionalStringValue *wrapperspb.StringValue         `protobuf:"bytes,10,opt,name=optional_string_value,json=optionalStringValue,proto3" json:"optional_string_value,omitempty"`
+	OptionalBytesValue  *wrapperspb.BytesValue          `protobuf:"bytes,11,opt,name=optional_bytes_value,json=optionalBytesValue,proto3" json:"optional_bytes_value,omitempty"`
+	RepeatedTimestamp   []*timestamppb.Timestamp        `protobuf:"bytes,12,rep,name=repeated_timestamp,json=repeatedTimestamp,proto3" json:"repeated_timestamp,omitempty"`
+	RepeatedInt32Value  []*wrapperspb.Int32Value        `protobuf:"bytes,13,rep,name=repeated_int32_value,json=repeatedInt32Value,proto3" json:"repeated_int32_value,omitempty"`
+	MapDuration         map[string]*durationpb.Duration `protobuf:"bytes,14,rep,name=map_duration,json=mapDuration,proto3" json:"map_duration,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	OptionalAny         *anypb.Any                      `protobuf:"bytes,15,opt,name=optional_any,json=optionalAny,proto3" json:"optional_any,omitempty"`
+	RepeatedAny         []*anypb.Any                    `protobuf:"bytes,16,rep,name=repeated_any,json=repeatedAny,proto3" json:"repeated_any,omitempty"`
+	OptionalStruct      *structpb.Struct                `protobuf:"bytes,17,opt,name=optional_struct,json=optionalStruct,proto3" json:"optional_struct,omitempty"`
+	OptionalValue       *structpb.Value                 `protobuf:"bytes,18,opt,name=optional_value,json=optionalValue,proto3" json:"optional_value,omitempty"`
+	OptionalListValue   *structpb.ListValue             `protobuf:"bytes,19,opt,name=optional_list_value,json=optionalListValue,proto3" json:"optional_list_value,omitempty"`
+	RepeatedValue       []*structpb.Value               `protobuf:"bytes,20,rep,name=repeated_value,json=repeatedValue,proto3" json:"repeated_value,omitempty"`
+	MapValue            map[string]*structpb.Value      `protobuf:"bytes,21,rep,name=map_value,json=mapValue,proto3" json:"map_value,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	OptionalNullValue   *structpb.NullValue             `protobuf:"varint,22,opt,name=optional_null_value,json=optionalNullValue,proto3,enum=google.protobuf.NullValue,oneof" json:"optional_null_value,omitempty"`
+	RepeatedNullValue   []structpb.NullValue            `protobuf:"varint,23,rep,packed,name=repeated_null_value,json=repeatedNullValue,proto3,enum=google.protobuf.NullValue" json:"repeated_null_value,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *TestWellKnownTypes) Reset() {
+	*x = TestWellKnownTypes{}
+	mi := &file_well_known_test_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TestWellKnownTypes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestWellKnownTypes) ProtoMessage() {}
+
+func (x *TestWellKnownTypes) ProtoReflect() protoreflect.Message {
+	mi := &file_well_known_test_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestWellKnownTypes.ProtoReflect.Descriptor instead.
+func (*TestWellKnownTypes) Descriptor() ([]byte, []int) {
+	return file_well_known_test_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TestWellKnownTypes) GetOptionalTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OptionalTimestamp
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalDuration() *durationpb.Duration {
+	if x != nil {
+		return x.OptionalDuration
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalDoubleValue() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.OptionalDoubleValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalFloatValue() *wrapperspb.FloatValue {
+	if x != nil {
+		return x.OptionalFloatValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalInt64Value() *wrapperspb.Int64Value {
+	if x != nil {
+		return x.OptionalInt64Value
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalUint64Value() *wrapperspb.UInt64Value {
+	if x != nil {
+		return x.OptionalUint64Value
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalInt32Value() *wrapperspb.Int32Value {
+	if x != nil {
+		return x.OptionalInt32Value
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalUint32Value() *wrapperspb.UInt32Value {
+	if x != nil {
+		return x.OptionalUint32Value
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalBoolValue() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.OptionalBoolValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalStringValue() *wrapperspb.StringValue {
+	if x != nil {
+		return x.OptionalStringValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalBytesValue() *wrapperspb.BytesValue {
+	if x != nil {
+		return x.OptionalBytesValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetRepeatedTimestamp() []*timestamppb.Timestamp {
+	if x != nil {
+		return x.RepeatedTimestamp
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetRepeatedInt32Value() []*wrapperspb.Int32Value {
+	if x != nil {
+		return x.RepeatedInt32Value
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetMapDuration() map[string]*durationpb.Duration {
+	if x != nil {
+		return x.MapDuration
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalAny() *anypb.Any {
+	if x != nil {
+		return x.OptionalAny
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetRepeatedAny() []*anypb.Any {
+	if x != nil {
+		return x.RepeatedAny
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalStruct() *structpb.Struct {
+	if x != nil {
+		return x.OptionalStruct
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalValue() *structpb.Value {
+	if x != nil {
+		return x.OptionalValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalListValue() *structpb.ListValue {
+	if x != nil {
+		return x.OptionalListValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetRepeatedValue() []*structpb.Value {
+	if x != nil {
+		return x.RepeatedValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetMapValue() map[string]*structpb.Value {
+	if x != nil {
+		return x.MapValue
+	}
+	return nil
+}
+
+func (x *TestWellKnownTypes) GetOptionalNullValue() structpb.NullValue {
+	if x != nil && x.OptionalNullValue != nil {
+		return *x.OptionalNullValue
+	}
+	return structpb.NullValue(0)
+}
+
+func (x *TestWellKnownTypes) GetRepeatedNullValue() []structpb.NullValue {
+	if x != nil {
+		return x.RepeatedNullValue
+	}
+	return nil
+}
+
+var File_well_known_test_proto protoreflect.FileDescriptor
+
+const file_well_known_test_proto_rawDesc = "" +
+	"\n" +
+	"\x15well_known_test.proto\x12\rwellknowntest\x1a\x19google/protobuf/any.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\"\x99\x0f\n" +
+	"\x12TestWellKnownTypes\x12I\n" +
+	"\x12optional_timestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x11optionalTimestamp\x12F\n" +
+	"\x11optional_duration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x10optionalDuration\x12P\n" +
+	"\x15optional_double_value\x18\x03 \x01(\v2\x1c.google.protobuf.DoubleValueR\x13optionalDoubleValue\x12M\n" +
+	"\x14optional_float_value\x18\x04 \x01(\v2\x1b.google.protobuf.FloatValueR\x12optionalFloatValue\x12M\n" +
+	"\x14optional_int64_value\x18\x05 \x01(\v2\x1b.google.protobuf.Int64ValueR\x12optionalInt64Value\x12P\n" +
+	"\x15optional_uint64_value\x18\x06 \x01(\v2\x1c.google.protobuf.UInt64ValueR\x13optionalUint64Value\x12M\n" +
+	"\x14optional_int32_value\x18\a \x01(\v2\x1b.google.protobuf.Int32ValueR\x12optionalInt32Value\x12P\n" +
+	"\x15optional_uint32_value\x18\b \x01(\v2\x1c.google.protobuf.UInt32ValueR\x13optionalUint32Value\x12J\n" +
+	"\x13optional_bool_value\x18\t \x01(\v2\x1a.google.protobuf.BoolValueR\x11optionalBoolValue\x12P\n" +
+	"\x15optional_string_value\x18\n" +
+	" \x01(\v2\x1c.google.protobuf.StringValueR\x13optionalStringValue\x12M\n" +
+	"\x14optional_bytes_value\x18\v \x01(\v2\x1b.google.protobuf.BytesValueR\x12optionalBytesValue\x12I\n" +
+	"\x12repeated_timestamp\x18\f \x03(\v2\x1a.google.protobuf.TimestampR\x11repeatedTimestamp\x12M\n" +
+	"\x14repeated_int32_value\x18\r \x03(\v2\x1b.google.protobuf.Int32ValueR\x12repeatedInt32Value\x12U\n" +
+	"\fmap_duration\x18\x0e \x03(\v22.wellknowntest.TestWellKnownTypes.MapDurationEntryR\vmapDuration\x127\n" +
+	"\foptional_any\x18\x0f \x01(\v2\x14.google.protobuf.AnyR\voptionalAny\x127\n" +
+	"\frepeated_any\x18\x10 \x03(\v2\x14.google.protobuf.AnyR\vrepeatedAny\x12@\n" +
+	"\x0foptional_struct\x18\x11 \x01(\v2\x17.google.protobuf.StructR\x0eoptionalStruct\x12=\n" +
+	"\x0eoptional_value\x18\x12 \x01(\v2\x16.google.protobuf.ValueR\roptionalValue\x12J\n" +
+	"\x13optional_list_value\x18\x13 \x01(\v2\x1a.google.protobuf.ListValueR\x11optionalListValue\x12=\n" +
+	"\x0erepeated_value\x18\x14 \x03(\v2\x16.google.protobuf.ValueR\rrepeatedValue\x12L\n" +
+	"\tmap_value\x18\x15 \x03(\v2/.wellknowntest.TestWellKnownTypes.MapValueEntryR\bmapValue\x12O\n" +
+	"\x13optional_null_value\x18\x16 \x01(\x0e2\x1a.google.protobuf.NullValueH\x00R\x11optionalNullValue\x88\x01\x01\x12J\n" +
+	"\x13repeated_null_value\x18\x17 \x03(\x0e2\x1a.google.protobuf.NullValueR\x11repeatedNullValue\x1aY\n" +
+	"\x10MapDurationEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
+	"\x05value\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x05value:\x028\x01\x1aS\n" +
+	"\rMapValueEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x05value:\x028\x01B\x16\n" +
+	"\x14_optional_null_valueB!Z\x1fprotoclosure/well_known_test_pbb\x06proto3"
+
+var (
+	file_well_known_test_proto_rawDescOnce sync.Once
+	file_well_known_test_proto_rawDescData []byte
+)
+
+func file_well_known_test_proto_rawDescGZIP() []byte {
+	file_well_known_test_proto_rawDescOnce.Do(func() {
+		file_well_known_test_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_well_known_test_proto_rawDesc), len(file_well_known_test_proto_rawDesc)))
+	})
+	return file_well_known_test_proto_rawDescData
+}
+
+var file_well_known_test_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_well_known_test_proto_goTypes = []any{
+	(*TestWellKnownTypes)(nil),     // 0: wellknowntest.TestWellKnownTypes
+	nil,                            // 1: wellknowntest.TestWellKnownTypes.MapDurationEntry
+	nil,                            // 2: wellknowntest.TestWellKnownTypes.MapValueEntry
+	(*timestamppb.Timestamp)(nil),  // 3: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 4: google.protobuf.Duration
+	(*wrapperspb.DoubleValue)(nil), // 5: google.protobuf.DoubleValue
+	(*wrapperspb.FloatValue)(nil),  // 6: google.protobuf.FloatValue
+	(*wrapperspb.Int64Value)(nil),  // 7: google.protobuf.Int64Value
+	(*wrapperspb.UInt64Value)(nil), // 8: google.protobuf.UInt64Value
+	(*wrapperspb.Int32Value)(nil),  // 9: google.protobuf.Int32Value
+	(*wrapperspb.UInt32Value)(nil), // 10: google.protobuf.UInt32Value
+	(*wrapperspb.BoolValue)(nil),   // 11: google.protobuf.BoolValue
+	(*wrapperspb.StringValue)(nil), // 12: google.protobuf.StringValue
+	(*wrapperspb.BytesValue)(nil),  // 13: google.protobuf.BytesValue
+	(*anypb.Any)(nil),              // 14: google.protobuf.Any
+	(*structpb.Struct)(nil),        // 15: google.protobuf.Struct
+	(*structpb.Value)(nil),         // 16: google.protobuf.Value
+	(*structpb.ListValue)(nil),     // 17: google.protobuf.ListValue
+	(structpb.NullValue)(0),        // 18: google.protobuf.NullValue
+}
+var file_well_known_test_proto_depIdxs = []int32{
+	3,  // 0: wellknowntest.TestWellKnownTypes.optional_timestamp:type_name -> google.protobuf.Timestamp
+	4,  // 1: wellknowntest.TestWellKnownTypes.optional_duration:type_name -> google.protobuf.Duration
+	5,  // 2: wellknowntest.TestWellKnownTypes.optional_double_value:type_name -> google.protobuf.DoubleValue
+	6,  // 3: wellknowntest.TestWellKnownTypes.optional_float_value:type_name -> google.protobuf.FloatValue
+	7,  // 4: wellknowntest.TestWellKnownTypes.optional_int64_value:type_name -> google.protobuf.Int64Value
+	8,  // 5: wellknowntest.TestWellKnownTypes.optional_uint64_value:type_name -> google.protobuf.UInt64Value
+	9,  // 6: wellknowntest.TestWellKnownTypes.optional_int32_value:type_name -> google.protobuf.Int32Value
+	10, // 7: wellknowntest.TestWellKnownTypes.optional_uint32_value:type_name -> google.protobuf.UInt32Value
+	11, // 8: wellknowntest.TestWellKnownTypes.optional_bool_value:type_name -> google.protobuf.BoolValue
+	12, // 9: wellknowntest.TestWellKnownTypes.optional_string_value:type_name -> google.protobuf.StringValue
+	13, // 10: wellknowntest.TestWellKnownTypes.optional_bytes_value:type_name -> google.protobuf.BytesValue
+	3,  // 11: wellknowntest.TestWellKnownTypes.repeated_timestamp:type_name -> google.protobuf.Timestamp
+	9,  // 12: wellknowntest.TestWellKnownTypes.repeated_int32_value:type_name -> google.protobuf.Int32Value
+	1,  // 13: wellknowntest.TestWellKnownTypes.map_duration:type_name -> wellknowntest.TestWellKnownTypes.MapDurationEntry
+	14, // 14: wellknowntest.TestWellKnownTypes.optional_any:type_name -> google.protobuf.Any
+	14, // 15: wellknowntest.TestWellKnownTypes.repeated_any:type_name -> google.protobuf.Any
+	15, // 16: wellknowntest.TestWellKnownTypes.optional_struct:type_name -> google.protobuf.Struct
+	16, // 17: wellknowntest.TestWellKnownTypes.optional_value:type_name -> google.protobuf.Value
+	17, // 18: wellknowntest.TestWellKnownTypes.optional_list_value:type_name -> google.protobuf.ListValue
+	16, // 19: wellknowntest.TestWellKnownTypes.repeated_value:type_name -> google.protobuf.Value
+	2,  // 20: wellknowntest.TestWellKnownTypes.map_value:type_name -> wellknowntest.TestWellKnownTypes.MapValueEntry
+	18, // 21: wellknowntest.TestWellKnownTypes.optional_null_value:type_name -> google.protobuf.NullValue
+	18, // 22: wellknowntest.TestWellKnownTypes.repeated_null_value:type_name -> google.protobuf.NullValue
+	4,  // 23: wellknowntest.TestWellKnownTypes.MapDurationEntry.value:type_name -> google.protobuf.Duration
+	16, // 24: wellknowntest.TestWellKnownTypes.MapValueEntry.value:type_name -> google.protobuf.Value
+	25, // [25:25] is the sub-list for method output_type
+	25, // [25:25] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
+}
+
+func init() { file_well_known_test_proto_init() }
+func file_well_known_test_proto_init() {
+	if File_well_known_test_proto != nil {
+		return
+	}
+	file_well_known_test_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_well_known_test_proto_rawDesc), len(file_well_known_test_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_well_known_test_proto_goTypes,
+		DependencyIndexes: file_well_known_test_proto_depIdxs,
+		MessageInfos:      file_well_known_test_proto_msgTypes,
+	}.Build()
+	File_well_known_test_proto = out.File
+	file_well_known_test_proto_goTypes = nil
+	file_well_known_test_proto_depIdxs = nil
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2014 SameGoal LLC. All Rights Reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoclosure
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	timestampName protoreflect.FullName = "google.protobuf.Timestamp"
+	durationName  protoreflect.FullName = "google.protobuf.Duration"
+	anyName       protoreflect.FullName = "google.protobuf.Any"
+	structName    protoreflect.FullName = "google.protobuf.Struct"
+	valueName     protoreflect.FullName = "google.protobuf.Value"
+	listValueName protoreflect.FullName = "google.protobuf.ListValue"
+	nullValueName protoreflect.FullName = "google.protobuf.NullValue"
+)
+
+// wrapperNames are the full names of the google.protobuf wrapper messages,
+// which are written as the bare value of their single "value" field.
+var wrapperNames = map[protoreflect.FullName]bool{
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+// Valid ranges of Timestamp and Duration seconds, from
+// 0001-01-01T00:00:00Z to 9999-12-31T23:59:59Z and up to +-10000 years.
+const (
+	minTimestampSeconds = -62135596800
+	maxTimestampSeconds = 253402300799
+	maxDurationSeconds  = 315576000000
+)
+
+var (
+	errInvalidDuration = errors.New("invalid duration string")
+	errMissingTypeURL  = errors.New("missing type URL")
+)
+
+// isWellKnown reports whether messages of type md have a JSON form of their
+// own when the WellKnownTypes option is set.
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	switch name := md.FullName(); name {
+	case timestampName, durationName, structName, valueName, listValueName:
+		return true
+	default:
+		return wrapperNames[name]
+	}
+}
+
+// readsNull reports whether a JSON null decoded for field fd is a value
+// rather than marking the field absent, as for google.protobuf.Value and
+// NullValue fields when the WellKnownTypes option is set.
+func readsNull(fd protoreflect.FieldDescriptor, wellKnownTypes bool) bool {
+	if !wellKnownTypes || fd.IsList() {
+		return false
+	}
+	return fd.Message() != nil && fd.Message().FullName() == valueName || isNullValue(fd)
+}
+
+// isNullValue reports whether fd is a google.protobuf.NullValue enum field,
+// whose single value is written as null when WellKnownTypes is set.
+func isNullValue(fd protoreflect.FieldDescriptor) bool {
+	return fd.Enum() != nil && fd.Enum().FullName() == nullValueName
+}
+
+// fromWellKnown converts the well-known type message msg into its JSON form.
+// Timestamps are written as RFC 3339 strings and Durations as seconds with an
+// "s" suffix, or both as integer milliseconds if millis is set. Struct, Value
+// and ListValue messages are written as the JSON values they represent.
+func fromWellKnown(msg protoreflect.Message, millis bool, bytesEnc *base64.Encoding) (interface{}, error) {
+	md := msg.Descriptor()
+	switch md.FullName() {
+	case timestampName:
+		secs, nanos := secondsNanos(msg)
+		if secs < minTimestampSeconds || secs > maxTimestampSeconds ||
+			nanos < 0 || nanos >= 1e9 {
+			return nil, fmt.Errorf("Invalid %v: %d seconds, %d nanos", md.FullName(), secs, nanos)
+		}
+		if millis {
+			return secs*1e3 + int64(nanos)/1e6, nil
+		}
+		t := time.Unix(secs, int64(nanos)).UTC()
+		return t.Format("2006-01-02T15:04:05") + fracSeconds(nanos) + "Z", nil
+
+	case durationName:
+		secs, nanos := secondsNanos(msg)
+		if secs < -maxDurationSeconds || secs > maxDurationSeconds ||
+			nanos <= -1e9 || nanos >= 1e9 ||
+			secs > 0 && nanos < 0 || secs < 0 && nanos > 0 {
+			return nil, fmt.Errorf("Invalid %v: %d seconds, %d nanos", md.FullName(), secs, nanos)
+		}
+		if millis {
+			return secs*1e3 + int64(nanos)/1e6, nil
+		}
+		sign := ""
+		if secs < 0 || nanos < 0 {
+			sign, secs, nanos = "-", -secs, -nanos
+		}
+		return sign + strconv.FormatInt(secs, 10) + fracSeconds(nanos) + "s", nil
+
+	case structName, valueName, listValueName:
+		return fromStructValue(msg)
+
+	default:
+		fd := md.Fields().ByName("value")
+		return fromProtoScalar(fd, msg.Get(fd), false, bytesEnc, false), nil
+	}
+}
+
+// toWellKnown sets the well-known type message msg from its decoded JSON
+// form v. Timestamps and Durations are accepted either as strings or as
+// integer milliseconds, and Struct, Value and ListValue messages as any JSON
+// value of the matching shape.
+func toWellKnown(v interface{}, msg protoreflect.Message, opts decodeOptions) error {
+	md := msg.Descriptor()
+	var secs int64
+	var nanos int32
+	var err error
+	switch md.FullName() {
+	case timestampName:
+		switch vt := v.(type) {
+		case string:
+			var t time.Time
+			t, err = time.Parse(time.RFC3339Nano, vt)
+			secs, nanos = t.Unix(), int32(t.Nanosecond())
+		case json.Number:
+			secs, nanos, err = fromMillis(vt, opts)
+			if err == nil && nanos < 0 {
+				// nanos count forward from the second before
+				secs, nanos = secs-1, nanos+1e9
+			}
+		default:
+			return newDecodeError(v, string(md.FullName()), nil)
+		}
+		if err == nil && (secs < minTimestampSeconds || secs > maxTimestampSeconds) {
+			err = strconv.ErrRange
+		}
+
+	case durationName:
+		switch vt := v.(type) {
+		case string:
+			secs, nanos, err = parseDuration(vt)
+		case json.Number:
+			secs, nanos, err = fromMillis(vt, opts)
+		default:
+			return newDecodeError(v, string(md.FullName()), nil)
+		}
+		if err == nil && (secs < -maxDurationSeconds || secs > maxDurationSeconds) {
+			err = strconv.ErrRange
+		}
+
+	case structName, valueName, listValueName:
+		return toStructValue(v, msg)
+
+	default:
+		fd := md.Fields().ByName("value")
+		pv, err := toProtoScalar(fd, v, opts)
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, pv)
+		return nil
+	}
+	if err != nil {
+		return newDecodeError(v, string(md.FullName()), err)
+	}
+	fields := md.Fields()
+	msg.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(secs))
+	msg.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(nanos))
+	return nil
+}
+
+// secondsNanos returns the fields of a Timestamp or Duration message.
+func secondsNanos(msg protoreflect.Message) (int64, int32) {
+	fields := msg.Descriptor().Fields()
+	return msg.Get(fields.ByName("seconds")).Int(),
+		int32(msg.Get(fields.ByName("nanos")).Int())
+}
+
+// fromMillis splits the JSON number of milliseconds n into seconds and
+// nanoseconds with the same sign.
+func fromMillis(n json.Number, opts decodeOptions) (int64, int32, error) {
+	ms, err := toProtoInt(n, 64, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ms / 1e3, int32(ms%1e3) * 1e6, nil
+}
+
+// fracSeconds formats nanos as a fraction of a second with 0, 3, 6 or 9
+// digits.
+func fracSeconds(nanos int32) string {
+	if nanos == 0 {
+		return ""
+	}
+	s := fmt.Sprintf(".%09d", nanos)
+	for strings.HasSuffix(s, "000") {
+		s = s[:len(s)-3]
+	}
+	return s
+}
+
+// parseDuration parses a Duration string such as "1.5s" or "-0.001s".
+func parseDuration(s string) (int64, int32, error) {
+	if !strings.HasSuffix(s, "s") {
+		return 0, 0, errInvalidDuration
+	}
+	s = strings.TrimSuffix(s, "s")
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	secs, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil || hasFrac && (frac == "" || len(frac) > 9) {
+		return 0, 0, errInvalidDuration
+	}
+	var nanos uint64
+	if hasFrac {
+		nanos, err = strconv.ParseUint(frac+strings.Repeat("0", 9-len(frac)), 10, 32)
+		if err != nil {
+			return 0, 0, errInvalidDuration
+		}
+	}
+	if secs > maxDurationSeconds {
+		return 0, 0, strconv.ErrRange
+	}
+	if neg {
+		return -int64(secs), -int32(nanos), nil
+	}
+	return int64(secs), int32(nanos), nil
+}
+
+// isAny reports whether messages of type md are google.protobuf.Any values.
+func isAny(md protoreflect.MessageDescriptor) bool {
+	return md.FullName() == anyName
+}
+
+// unpackAny returns the type URL of the Any message msg and the message it
+// contains, whose type is looked up with resolver. The message is nil if msg
+// is empty.
+func unpackAny(msg protoreflect.Message, resolver protoregistry.MessageTypeResolver) (string, protoreflect.Message, error) {
+	fields := msg.Descriptor().Fields()
+	url := msg.Get(fields.ByName("type_url")).String()
+	if url == "" && len(msg.Get(fields.ByName("value")).Bytes()) == 0 {
+		return "", nil, nil
+	}
+	mt, err := resolver.FindMessageByURL(url)
+	if err != nil {
+		return "", nil, fmt.Errorf("Cannot resolve %v type %q: %v", anyName, url, err)
+	}
+	inner := mt.New()
+	err = proto.UnmarshalOptions{AllowPartial: true}.Unmarshal(
+		msg.Get(fields.ByName("value")).Bytes(), inner.Interface())
+	if err != nil {
+		return "", nil, fmt.Errorf("Cannot unpack %v of type %q: %v", anyName, url, err)
+	}
+	return url, inner, nil
+}
+
+// packAny sets the Any message msg to contain inner under the type URL url.
+func packAny(msg protoreflect.Message, url string, inner protoreflect.Message) error {
+	b, err := proto.MarshalOptions{AllowPartial: true, Deterministic: true}.Marshal(inner.Interface())
+	if err != nil {
+		return err
+	}
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName("type_url"), protoreflect.ValueOfString(url))
+	msg.Set(fields.ByName("value"), protoreflect.ValueOfBytes(b))
+	return nil
+}
+
+// newAnyMessage returns a new message of the type named by the Any type URL
+// v, looked up with resolver.
+func newAnyMessage(v interface{}, resolver protoregistry.MessageTypeResolver) (string, protoreflect.Message, error) {
+	url, ok := v.(string)
+	if !ok {
+		var err error
+		if v == nil {
+			err = errMissingTypeURL
+		}
+		return "", nil, newDecodeError(v, string(anyName), err)
+	}
+	mt, err := resolver.FindMessageByURL(url)
+	if err != nil {
+		return "", nil, newDecodeError(v, string(anyName), err)
+	}
+	return url, mt.New(), nil
+}
+
+// fromStructValue converts the Struct, Value or ListValue message msg into
+// the JSON value it represents.
+func fromStructValue(msg protoreflect.Message) (interface{}, error) {
+	md := msg.Descriptor()
+	fields := md.Fields()
+	switch md.FullName() {
+	case structName:
+		mp := msg.Get(fields.ByName("fields")).Map()
+		obj := make(map[string]interface{}, mp.Len())
+		var err error
+		mp.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			obj[k.String()], err = fromStructValue(v.Message())
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case listValueName:
+		list := msg.Get(fields.ByName("values")).List()
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			var err error
+			items[i], err = fromStructValue(list.Get(i).Message())
+			if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+
+	default:
+		fd := msg.WhichOneof(md.Oneofs().ByName("kind"))
+		if fd == nil {
+			return nil, fmt.Errorf("Invalid %v: no kind set", md.FullName())
+		}
+		v := msg.Get(fd)
+		switch fd.Name() {
+		case "null_value":
+			return nil, nil
+		case "number_value":
+			return v.Float(), nil
+		case "string_value":
+			return v.String(), nil
+		case "bool_value":
+			return v.Bool(), nil
+		default:
+			return fromStructValue(v.Message())
+		}
+	}
+}
+
+// toStructValue sets the Struct, Value or ListValue message msg from the
+// decoded JSON value v.
+func toStructValue(v interface{}, msg protoreflect.Message) error {
+	md := msg.Descriptor()
+	fields := md.Fields()
+	switch md.FullName() {
+	case structName:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return newDecodeError(v, string(md.FullName()), nil)
+		}
+		// decode entries in a stable order so that errors are reported
+		// deterministically
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		mp := msg.Mutable(fields.ByName("fields")).Map()
+		for _, k := range keys {
+			pv := mp.NewValue()
+			err := toStructValue(obj[k], pv.Message())
+			if err != nil {
+				return atIndex(err, k)
+			}
+			mp.Set(protoreflect.ValueOfString(k).MapKey(), pv)
+		}
+		return nil
+
+	case listValueName:
+		items, ok := v.([]interface{})
+		if !ok {
+			return newDecodeError(v, string(md.FullName()), nil)
+		}
+		list := msg.Mutable(fields.ByName("values")).List()
+		for i, item := range items {
+			pv := list.NewElement()
+			err := toStructValue(item, pv.Message())
+			if err != nil {
+				return atIndex(err, i)
+			}
+			list.Append(pv)
+		}
+		return nil
+	}
+
+	switch vt := v.(type) {
+	case nil:
+		msg.Set(fields.ByName("null_value"), protoreflect.ValueOfEnum(0))
+	case json.Number:
+		f, err := vt.Float64()
+		if err != nil {
+			return newDecodeError(v, string(md.FullName()), err)
+		}
+		msg.Set(fields.ByName("number_value"), protoreflect.ValueOfFloat64(f))
+	case string:
+		msg.Set(fields.ByName("string_value"), protoreflect.ValueOfString(vt))
+	case bool:
+		msg.Set(fields.ByName("bool_value"), protoreflect.ValueOfBool(vt))
+	case map[string]interface{}:
+		return toStructValue(vt, msg.Mutable(fields.ByName("struct_value")).Message())
+	case []interface{}:
+		return toStructValue(vt, msg.Mutable(fields.ByName("list_value")).Message())
+	default:
+		return newDecodeError(v, string(md.FullName()), nil)
+	}
+	return nil
+}